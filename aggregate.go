@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// aggregateHeader lists the CSV columns written in --aggregate mode
+var aggregateHeader = []string{"timestamp", "count", "min", "max", "mean", "mode", "freqMode", "range"}
+
+// windowSummary is the record emitted for each --aggregate window
+type windowSummary struct {
+	Timestamp string  `json:"timestamp"` // Start of the window
+	Count     int     `json:"count"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Mean      float64 `json:"mean"` // Energy average, i.e. the Leq of the window
+	Mode      string  `json:"mode"`
+	FreqMode  string  `json:"freqMode"`
+	Range     string  `json:"range"`
+	Serial    string  `json:"serial,omitempty"`
+	metadata
+
+	start time.Time // Unformatted Timestamp, for the --csv-epoch column
+}
+
+// aggregator buffers readings into fixed windows aligned to the clock, such as every full 10 seconds
+type aggregator struct {
+	window time.Duration
+
+	start     time.Time
+	count     int
+	min       float64
+	max       float64
+	energySum float64
+	last      gm1356.DecibelReading
+}
+
+// add records a reading, returning the summary of the previous window once a reading falls into a new one or the weighting changes, so a window never mixes dBA and dBC
+func (a *aggregator) add(data gm1356.DecibelReading) (windowSummary, bool) {
+	start := data.Time.Truncate(a.window)
+	summary, done := windowSummary{}, false
+	if a.count > 0 && (!start.Equal(a.start) || data.FreqMode != a.last.FreqMode) {
+		summary, done = a.drain()
+	}
+
+	if a.count == 0 {
+		a.start = start
+		a.min, a.max = data.Measured, data.Measured
+	}
+	a.min = math.Min(a.min, data.Measured)
+	a.max = math.Max(a.max, data.Measured)
+	a.energySum += gm1356.Energy(data.Measured)
+	a.count++
+	a.last = data
+	return summary, done
+}
+
+// drain returns the summary of the current window, if it has any readings, and starts a new one
+func (a *aggregator) drain() (windowSummary, bool) {
+	if a.count == 0 {
+		return windowSummary{}, false
+	}
+	timestamp, ok := formatTimestamp(a.start, tsFormat)
+	if !ok {
+		timestamp = a.start.Format(gm1356.TimestampLayout)
+	}
+	summary := windowSummary{
+		Timestamp: timestamp,
+		Count:     a.count,
+		Min:       roundLevel(a.min),
+		Max:       roundLevel(a.max),
+		Mean:      roundLevel(gm1356.Level(a.energySum / float64(a.count))),
+		Mode:      a.last.Mode,
+		FreqMode:  a.last.FreqMode,
+		Range:     a.last.Range,
+		Serial:    a.last.Serial,
+		metadata:  meta,
+		start:     a.start,
+	}
+	a.count = 0
+	a.energySum = 0
+	return summary, true
+}
+
+// writeSummary prints a window summary and logs it to CSV, in place of the individual readings
+func (o outputs) writeSummary(summary windowSummary) {
+	jsonData, err := json.Marshal(summary)
+	if err != nil {
+		slog.Error("Failed to encode window summary, skipping it", "err", err, "timestamp", summary.Timestamp, "count", summary.Count)
+		return
+	}
+	if o.tui == nil && !o.jsonOut.toStdout() && (!quiet || o.csvWriter == nil) {
+		fmt.Println(stdoutLine(summary.Mean, summary.FreqMode, jsonData))
+	}
+	if err := o.jsonOut.write(jsonData); err != nil {
+		slog.Error("Failed to write to output file", "err", err)
+	}
+
+	if o.csvWriter != nil {
+		record := []string{summary.Timestamp, strconv.Itoa(summary.Count), formatLevel(summary.Min), formatLevel(summary.Max), formatLevel(summary.Mean), summary.Mode, summary.FreqMode, summary.Range}
+		if multiDevice {
+			record = append(record, summary.Serial)
+		}
+		if csvEpoch {
+			record = append(record, strconv.FormatInt(summary.start.UnixMilli(), 10))
+		}
+		record = append(record, meta.labelValues()...)
+		if err := o.csvWriter.Write(record); err != nil {
+			slog.Error("Failed to write to CSV log", "err", err)
+		}
+	}
+}
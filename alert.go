@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// alerter fires when readings stay above a threshold for a sustained duration; a nil *alerter is a no-op
+type alerter struct {
+	threshold float64
+	duration  time.Duration
+	command   string
+
+	aboveSince time.Time // Start of the current loud period, zero while below the threshold
+	firing     bool      // Alert already fired for the current loud period
+	anyFired   atomic.Bool
+}
+
+// check updates the alert state with a new reading, firing once per sustained loud period
+func (a *alerter) check(at time.Time, data gm1356.DecibelReading) {
+	if a == nil {
+		return
+	}
+	if data.Measured <= a.threshold {
+		a.aboveSince = time.Time{}
+		a.firing = false
+		return
+	}
+
+	if a.aboveSince.IsZero() {
+		a.aboveSince = at
+	}
+	if a.firing || at.Sub(a.aboveSince) < a.duration {
+		return
+	}
+
+	a.firing = true
+	a.anyFired.Store(true)
+	slog.Warn("ALERT: Level above threshold", "threshold", a.threshold, "for", at.Sub(a.aboveSince).Round(time.Second), "measured", data.Measured)
+	if a.command != "" {
+		go a.runCommand(data)
+	}
+}
+
+// fired reports whether any alert fired during the session
+func (a *alerter) fired() bool {
+	return a != nil && a.anyFired.Load()
+}
+
+// runCommand runs the --on-alert shell command with the triggering reading in its environment
+func (a *alerter) runCommand(data gm1356.DecibelReading) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", a.command)
+	} else {
+		cmd = exec.Command("sh", "-c", a.command)
+	}
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("DECIBEL_MEASURED=%.1f", data.Measured),
+		fmt.Sprintf("DECIBEL_THRESHOLD=%.1f", a.threshold),
+		"DECIBEL_TIMESTAMP="+data.Timestamp,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		slog.Warn("--on-alert command failed", "err", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// alertTestSample is a level checked by an alerter, offset from a fixed start time
+type alertTestSample struct {
+	offset   time.Duration
+	measured float64
+}
+
+func TestAlerterCheck(t *testing.T) {
+	start := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		samples    []alertTestSample
+		wantFiring bool
+		wantFired  bool
+	}{
+		{"below threshold", 10 * time.Second, []alertTestSample{{0, 70}, {20 * time.Second, 80}}, false, false},
+		{"brief spike", 10 * time.Second, []alertTestSample{{0, 90}, {5 * time.Second, 90}}, false, false},
+		{"sustained", 10 * time.Second, []alertTestSample{{0, 90}, {5 * time.Second, 90}, {10 * time.Second, 90}}, true, true},
+		{"no duration fires at once", 0, []alertTestSample{{0, 90}}, true, true},
+		{"dip restarts the period", 10 * time.Second, []alertTestSample{{0, 90}, {5 * time.Second, 70}, {12 * time.Second, 90}}, false, false},
+		{"re-arms after dropping", 10 * time.Second, []alertTestSample{{0, 90}, {10 * time.Second, 90}, {11 * time.Second, 70}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &alerter{threshold: 80, duration: tt.duration}
+			for _, s := range tt.samples {
+				a.check(start.Add(s.offset), gm1356.DecibelReading{Measured: s.measured})
+			}
+			if a.firing != tt.wantFiring || a.fired() != tt.wantFired {
+				t.Errorf("after %d readings firing = %t, fired() = %t, want %t, %t", len(tt.samples), a.firing, a.fired(), tt.wantFiring, tt.wantFired)
+			}
+		})
+	}
+
+	var a *alerter
+	a.check(start, gm1356.DecibelReading{Measured: 120})
+	if a.fired() {
+		t.Error("nil alerter fired")
+	}
+}
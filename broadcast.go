@@ -0,0 +1,76 @@
+package main
+
+import "sync"
+
+// subscriberBuffer is how many messages a subscriber may fall behind before it is dropped
+const subscriberBuffer = 16
+
+// broadcaster fans messages out to any number of subscribers without ever blocking the publisher; a nil *broadcaster is a no-op
+type broadcaster[T any] struct {
+	mu          sync.Mutex
+	subscribers map[chan T]struct{}
+	closed      bool
+}
+
+// newBroadcaster creates a broadcaster with no subscribers
+func newBroadcaster[T any]() *broadcaster[T] {
+	return &broadcaster[T]{subscribers: make(map[chan T]struct{})}
+}
+
+// subscribe registers a new subscriber; its channel is closed when it is dropped or the broadcaster is closed
+func (b *broadcaster[T]) subscribe() chan T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan T, subscriberBuffer)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe removes a subscriber that went away on its own
+func (b *broadcaster[T]) unsubscribe(ch chan T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish sends msg to every subscriber, dropping any subscriber whose buffer is full
+func (b *broadcaster[T]) publish(msg T) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// close drops every subscriber and rejects new ones
+func (b *broadcaster[T]) close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	b.closed = true
+}
@@ -0,0 +1,31 @@
+package main
+
+import "time"
+
+// burstInterval is the delay between burst readings; the device's answer time dominates, this only keeps sources
+// without one, like the simulator, from spinning
+const burstInterval = 10 * time.Millisecond
+
+// burstCapture tracks a --burst-duration window of back-to-back readings, opened when the level crosses the trigger
+type burstCapture struct {
+	trigger  float64
+	duration time.Duration // 0 disables bursts
+	until    time.Time     // End of the current burst, zero if none is running
+	above    bool          // Level was at or above the trigger on the previous reading, so a sustained level doesn't retrigger
+}
+
+// active reports whether a burst is running at now, so the --interval delay should be skipped
+func (b *burstCapture) active(now time.Time) bool {
+	return now.Before(b.until)
+}
+
+// check opens a burst if level rises to the trigger and none is running; it reports whether one was started
+func (b *burstCapture) check(level float64, now time.Time) bool {
+	crossed := level >= b.trigger && !b.above
+	b.above = level >= b.trigger
+	if b.duration == 0 || !crossed || b.active(now) {
+		return false
+	}
+	b.until = now.Add(b.duration)
+	return true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// loadConfigFile sets every flag named in a JSON config file, except those given explicitly on the command line
+func loadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep numbers as written so they parse like command-line values
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if name == "config" || flag.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown option %q", path, name)
+		}
+		if explicit[name] {
+			continue
+		}
+
+		var text string
+		switch value := values[name].(type) {
+		case string:
+			text = value
+		case json.Number:
+			text = value.String()
+		case bool:
+			text = strconv.FormatBool(value)
+		default:
+			return fmt.Errorf("%s: option %q must be a string, number, or boolean", path, name)
+		}
+		if err := flag.Set(name, text); err != nil {
+			return fmt.Errorf("%s: option %q: %v", path, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// csvHeader lists the default columns; the header is written at the top of every new CSV file so each rotated segment is self-describing
+var csvHeader = []string{"timestamp", "measured", "mode", "freqMode", "range"}
+
+// lastRowWindow is how much of the end of a log lastLoggedTime reads to find the last row
+const lastRowWindow = 64 << 10
+
+// csvDialect controls how CSV rows are written, for tools that expect something other than comma-separated values with a header
+type csvDialect struct {
+	comma    rune // Field delimiter; 0 means a comma
+	noHeader bool // Skip the header row in new files
+	crlf     bool // End lines with \r\n instead of \n
+}
+
+// parseCSVDelimiter parses a --csv-delimiter value: a single character, or "tab"
+func parseCSVDelimiter(s string) (rune, error) {
+	if s == "tab" || s == `\t` {
+		return '\t', nil
+	}
+	runes := []rune(s)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' || runes[0] == utf8.RuneError {
+		return 0, fmt.Errorf("delimiter must be a single character other than a quote or newline, or \"tab\", got %q", s)
+	}
+	return runes[0], nil
+}
+
+// csvLog writes CSV rows to a file, rotating it once it grows past maxSize bytes or gets older than maxAge
+type csvLog struct {
+	filename string
+	header   []string
+	dialect  csvDialect
+	flush    flushPolicy
+	maxSize  int64         // 0 disables size-based rotation
+	maxAge   time.Duration // 0 disables age-based rotation
+
+	file      *os.File
+	written   *countingWriter // Bytes in the file, counting everything flushed to it
+	buffer    *bufio.Writer   // Rows the csv.Writer has buffered but not yet flushed
+	writer    *csv.Writer
+	opened    time.Time
+	pending   int // Rows written since the last flush
+	lastFlush time.Time
+}
+
+// setupCSVLog opens the CSV file for logging and writes headers if the file is new, unless the dialect skips them.
+func setupCSVLog(filename string, header []string, dialect csvDialect, flush flushPolicy, maxSize int64, maxAge time.Duration) (*csvLog, error) {
+	l := &csvLog{filename: filename, header: header, dialect: dialect, flush: flush, maxSize: maxSize, maxAge: maxAge, lastFlush: time.Now()}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open opens the active file for appending, writing the header if the file is new
+func (l *csvLog) open() error {
+	fileExists := fileExists(l.filename)
+
+	file, err := os.OpenFile(l.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	l.file = file
+	l.written = &countingWriter{w: file, n: size}
+	l.buffer = bufio.NewWriter(l.written)
+	l.writer = csv.NewWriter(l.buffer) // Wraps l.buffer itself rather than buffering again, so Buffered reflects its rows
+	if l.dialect.comma != 0 {
+		l.writer.Comma = l.dialect.comma
+	}
+	l.writer.UseCRLF = l.dialect.crlf
+	l.opened = time.Now()
+	if !fileExists && !l.dialect.noHeader {
+		// Write CSV header only if the file is new
+		l.writer.Write(l.header)
+		l.writer.Flush()
+	}
+	return l.writer.Error()
+}
+
+// Write writes a row, rotating the file first if it has reached its size or age limit, and flushes it once the flush policy says so
+func (l *csvLog) Write(record []string) error {
+	if l.needsRotation() {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	if err := l.writer.Write(record); err != nil {
+		return err
+	}
+	l.pending++
+	if l.flush.due(l.pending, l.lastFlush) {
+		l.Flush()
+		return l.writer.Error()
+	}
+	return nil
+}
+
+// Flush writes any buffered rows to the file
+func (l *csvLog) Flush() {
+	l.writer.Flush()
+	l.pending = 0
+	l.lastFlush = time.Now()
+}
+
+// Error reports any error from a previous Write or Flush
+func (l *csvLog) Error() error {
+	return l.writer.Error()
+}
+
+// Close flushes buffered rows and closes the active file
+func (l *csvLog) Close() error {
+	l.writer.Flush()
+	if err := l.writer.Error(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// needsRotation reports whether the active file has reached a configured limit, counting buffered rows without flushing them
+func (l *csvLog) needsRotation() bool {
+	if l.maxAge > 0 && time.Since(l.opened) >= l.maxAge {
+		return true
+	}
+	return l.maxSize > 0 && l.written.n+int64(l.buffer.Buffered()) >= l.maxSize
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write writes p to the underlying writer, adding what was written to the count
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// rotate renames the active file with a timestamp suffix and starts a fresh one with headers
+func (l *csvLog) rotate() error {
+	if err := l.Close(); err != nil {
+		return err
+	}
+
+	rotated := rotatedName(l.filename, time.Now())
+	if err := os.Rename(l.filename, rotated); err != nil {
+		// Keep logging to the existing file rather than losing rows
+		slog.Error("Failed to rotate log file", "file", l.filename, "err", err)
+		return l.open()
+	}
+	slog.Info("Rotated log file", "file", rotated)
+	return l.open()
+}
+
+// resume reports the time since the last row of an existing log, warning about it and optionally writing a marker row once it exceeds gap
+func (l *csvLog) resume(last, now time.Time, gap time.Duration, marker bool) error {
+	offline := now.Sub(last).Round(time.Second)
+	if offline <= gap {
+		slog.Info("Resuming log file", "file", l.filename, "lastRow", last, "since", offline)
+		return nil
+	}
+	slog.Warn("Resuming log file after an outage, the meter was offline", "file", l.filename, "lastRow", last, "offline", offline)
+	if !marker {
+		return nil
+	}
+
+	// Leave every value blank, so the marker reads as missing data rather than a level
+	row := make([]string, len(l.header))
+	column := slices.Index(l.header, "timestamp")
+	if column < 0 {
+		return fmt.Errorf("no timestamp column to mark the outage in")
+	}
+	row[column], _ = formatTimestamp(now, tsFormat)
+	if row[column] == "" {
+		row[column] = now.Format(gm1356.TimestampLayout)
+	}
+	if i := slices.Index(l.header, "epochMs"); i >= 0 {
+		row[i] = strconv.FormatInt(now.UnixMilli(), 10)
+	}
+	return l.Write(row)
+}
+
+// lastLoggedTime returns the timestamp in the given column of the last row in a CSV log, reading only the end of the file; found is false for a missing, empty, or header-only file
+func lastLoggedTime(filename string, comma rune, column int, loc *time.Location) (time.Time, bool, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	offset := max(info.Size()-lastRowWindow, 0)
+	tail := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(tail, offset); err != nil {
+		return time.Time{}, false, err
+	}
+	text := strings.TrimRight(string(tail), "\r\n")
+	line := text[strings.LastIndexByte(text, '\n')+1:]
+	if line == "" || (offset > 0 && len(line) == len(text)) {
+		return time.Time{}, false, nil // Empty file, or a last line longer than the window
+	}
+
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = comma
+	fields, err := reader.Read()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("last row: %v", err)
+	}
+	if column >= len(fields) {
+		return time.Time{}, false, fmt.Errorf("last row: no column %d", column+1)
+	}
+	if fields[column] == "timestamp" {
+		return time.Time{}, false, nil
+	}
+	// The default layout only names the zone, so read it in the zone the new rows are written in
+	if at, err := time.ParseInLocation(gm1356.TimestampLayout, fields[column], loc); err == nil {
+		return at, true, nil
+	}
+	at, err := parseReplayTimestamp(fields[column])
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("last row: %v", err)
+	}
+	return at, true, nil
+}
+
+// rotatedName inserts a timestamp before the extension, e.g. log.csv becomes log-20250301T050400.csv
+func rotatedName(filename string, at time.Time) string {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	name := fmt.Sprintf("%s-%s%s", base, at.Format("20060102T150405"), ext)
+
+	// Avoid clobbering a segment rotated within the same second
+	for i := 1; fileExists(name); i++ {
+		name = fmt.Sprintf("%s-%s-%d%s", base, at.Format("20060102T150405"), i, ext)
+	}
+	return name
+}
+
+// fileExists checks if a file exists
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return !os.IsNotExist(err)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testRow is a CSV row of the default columns, testRowSize bytes with its newline
+var testRow = []string{"2025-03-01 05:04:00 UTC", "42.0", "slow", "dBA", "30-130"}
+
+const testRowSize = int64(len("2025-03-01 05:04:00 UTC,42.0,slow,dBA,30-130\n"))
+
+func TestCSVLogRotation(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxSize   int64
+		maxAge    time.Duration
+		age       time.Duration // How old the active file is made before the first row
+		flush     flushPolicy
+		rows      int
+		wantFiles int
+	}{
+		{"no limits", 0, 0, 0, flushPolicy{}, 10, 1},
+		{"size not reached", 1000, 0, 0, flushPolicy{}, 10, 1},
+		{"size reached", 100, 0, 0, flushPolicy{}, 5, 3},
+		{"size counts buffered rows", 100, 0, 0, flushPolicy{rows: 1000}, 5, 3},
+		{"age not reached", 0, time.Hour, 0, flushPolicy{}, 5, 1},
+		{"age reached", 0, time.Hour, 2 * time.Hour, flushPolicy{}, 5, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := setupCSVLog(filepath.Join(dir, "readings.csv"), csvHeader, csvDialect{}, tt.flush, tt.maxSize, tt.maxAge)
+			if err != nil {
+				t.Fatalf("setupCSVLog() error = %v", err)
+			}
+			l.opened = l.opened.Add(-tt.age)
+			for range tt.rows {
+				if err := l.Write(testRow); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.wantFiles {
+				t.Errorf("%d rows left %d files, want %d", tt.rows, len(entries), tt.wantFiles)
+			}
+			for _, entry := range entries {
+				info, _ := entry.Info()
+				if tt.maxSize > 0 && info.Size() > tt.maxSize+testRowSize {
+					t.Errorf("%s is %d bytes, more than a row past the %d byte limit", entry.Name(), info.Size(), tt.maxSize)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVLogMaxSizeKeepsBuffering(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "readings.csv")
+	l, err := setupCSVLog(filename, csvHeader, csvDialect{}, flushPolicy{rows: 100}, 1<<20, 0)
+	if err != nil {
+		t.Fatalf("setupCSVLog() error = %v", err)
+	}
+	defer l.Close()
+
+	header, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if err := l.Write(testRow); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	// Checking the size limit must not flush the rows --flush-rows is still holding back
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != header.Size() {
+		t.Errorf("file is %d bytes after 10 buffered rows, want only the %d byte header", info.Size(), header.Size())
+	}
+}
+
+func TestRotatedName(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	filename := filepath.Join(dir, "readings.csv")
+
+	first := rotatedName(filename, at)
+	if want := filepath.Join(dir, "readings-20250301T050400.csv"); first != want {
+		t.Errorf("rotatedName() = %q, want %q", first, want)
+	}
+	if err := os.WriteFile(first, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rotatedName(filename, at), filepath.Join(dir, "readings-20250301T050400-1.csv"); got != want {
+		t.Errorf("rotatedName() with a segment from the same second = %q, want %q", got, want)
+	}
+}
+
+func TestLastLoggedTime(t *testing.T) {
+	at := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		content   string
+		column    int
+		want      time.Time
+		wantFound bool
+		wantErr   bool
+	}{
+		{"default layout", "timestamp,measured\n2025-03-01 05:03:59 UTC,41.0\n2025-03-01 05:04:00 UTC,42.0\n", 0, at, true, false},
+		{"rfc3339", "timestamp,measured\n2025-03-01T05:04:00.000Z,42.0\n", 0, at, true, false},
+		{"unix millis", "timestamp,measured\n1740805440000,42.0\n", 0, at, true, false},
+		{"crlf and trailing blank line", "timestamp,measured\r\n2025-03-01 05:04:00 UTC,42.0\r\n\r\n", 0, at, true, false},
+		{"timestamp not first", "measured,timestamp\n42.0,2025-03-01 05:04:00 UTC\n", 1, at, true, false},
+		{"empty file", "", 0, time.Time{}, false, false},
+		{"header only", "timestamp,measured\n", 0, time.Time{}, false, false},
+		{"header only, timestamp not first", "measured,timestamp\n", 1, time.Time{}, false, false},
+		{"unparseable timestamp", "timestamp,measured\nyesterday,42.0\n", 0, time.Time{}, false, true},
+		{"short last row", "measured,timestamp\n42.0\n", 1, time.Time{}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "readings.csv")
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, found, err := lastLoggedTime(filename, ',', tt.column, time.UTC)
+			if (err != nil) != tt.wantErr || found != tt.wantFound || !got.Equal(tt.want) {
+				t.Errorf("lastLoggedTime() = %v, %t, %v, want %v, %t, wantErr %t", got, found, err, tt.want, tt.wantFound, tt.wantErr)
+			}
+		})
+	}
+
+	if _, found, err := lastLoggedTime(filepath.Join(t.TempDir(), "missing.csv"), ',', 0, time.UTC); found || err != nil {
+		t.Errorf("lastLoggedTime() of a missing file = %t, %v, want not found without an error", found, err)
+	}
+}
+
+func TestCSVLogResume(t *testing.T) {
+	last := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		header  []string
+		offline time.Duration
+		marker  bool
+		want    string // Rows written after the header
+	}{
+		{"within the gap", csvHeader, 30 * time.Second, true, ""},
+		{"outage without marker", csvHeader, time.Hour, false, ""},
+		{"outage with marker", csvHeader, time.Hour, true, "2025-03-01 06:04:00 UTC,,,,\n"},
+		{"marker with timestamp not first", []string{"measured", "timestamp", "epochMs"}, time.Hour, true, ",2025-03-01 06:04:00 UTC,1740809040000\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "readings.csv")
+			l, err := setupCSVLog(filename, tt.header, csvDialect{noHeader: true}, flushPolicy{}, 0, 0)
+			if err != nil {
+				t.Fatalf("setupCSVLog() error = %v", err)
+			}
+			if err := l.resume(last, last.Add(tt.offline), time.Minute, tt.marker); err != nil {
+				t.Fatalf("resume() error = %v", err)
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("resume() after %v wrote %q, want %q", tt.offline, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// dashboardConfig is served as /config.json so the page can draw threshold lines
+type dashboardConfig struct {
+	Thresholds []float64 `json:"thresholds"`
+}
+
+// startDashboard serves the embedded live chart page at addr along with the WebSocket feed it reads from; it returns once the listener is bound
+func startDashboard(addr string, feed *broadcaster[[]byte], thresholds []float64) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	config := dashboardConfig{Thresholds: thresholds}
+	if config.Thresholds == nil {
+		config.Thresholds = []float64{}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServerFS(page))
+	mux.Handle("/ws", websocketHandler(feed))
+	mux.HandleFunc("/config.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, config)
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
@@ -0,0 +1,280 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: decibel.proto
+
+package decibelpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Reading mirrors gm1356.DecibelReading, except for the config byte, extra packet bytes, and weighting estimate
+type Reading struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Timestamp     string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Formatted as in the JSON output
+	Measured      float64                `protobuf:"fixed64,3,opt,name=measured,proto3" json:"measured,omitempty"` // Calibrated level in dB
+	RawMeasured   float64                `protobuf:"fixed64,4,opt,name=raw_measured,json=rawMeasured,proto3" json:"raw_measured,omitempty"`
+	Mode          string                 `protobuf:"bytes,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	FreqMode      string                 `protobuf:"bytes,6,opt,name=freq_mode,json=freqMode,proto3" json:"freq_mode,omitempty"`
+	Range         string                 `protobuf:"bytes,7,opt,name=range,proto3" json:"range,omitempty"`
+	MaxHold       bool                   `protobuf:"varint,8,opt,name=max_hold,json=maxHold,proto3" json:"max_hold,omitempty"`
+	OutOfRange    bool                   `protobuf:"varint,9,opt,name=out_of_range,json=outOfRange,proto3" json:"out_of_range,omitempty"`
+	Serial        string                 `protobuf:"bytes,10,opt,name=serial,proto3" json:"serial,omitempty"`
+	Raw           string                 `protobuf:"bytes,11,opt,name=raw,proto3" json:"raw,omitempty"`                                  // Hex-encoded packet, with --include-raw
+	Leq           float64                `protobuf:"fixed64,12,opt,name=leq,proto3" json:"leq,omitempty"`                                // Rolling Leq, with --leq-window
+	Smoothed      float64                `protobuf:"fixed64,13,opt,name=smoothed,proto3" json:"smoothed,omitempty"`                      // Moving average, with --smooth
+	Seq           uint64                 `protobuf:"varint,14,opt,name=seq,proto3" json:"seq,omitempty"`                                 // Per-meter sequence number, to spot gaps in the stream
+	BatteryLow    bool                   `protobuf:"varint,15,opt,name=battery_low,json=batteryLow,proto3" json:"battery_low,omitempty"` // With --decode-battery
+	UnknownConfig bool                   `protobuf:"varint,16,opt,name=unknown_config,json=unknownConfig,proto3" json:"unknown_config,omitempty"`
+	Stale         bool                   `protobuf:"varint,17,opt,name=stale,proto3" json:"stale,omitempty"` // Packet repeats the previous one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reading) Reset() {
+	*x = Reading{}
+	mi := &file_decibel_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reading) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reading) ProtoMessage() {}
+
+func (x *Reading) ProtoReflect() protoreflect.Message {
+	mi := &file_decibel_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reading.ProtoReflect.Descriptor instead.
+func (*Reading) Descriptor() ([]byte, []int) {
+	return file_decibel_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Reading) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Reading) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *Reading) GetMeasured() float64 {
+	if x != nil {
+		return x.Measured
+	}
+	return 0
+}
+
+func (x *Reading) GetRawMeasured() float64 {
+	if x != nil {
+		return x.RawMeasured
+	}
+	return 0
+}
+
+func (x *Reading) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Reading) GetFreqMode() string {
+	if x != nil {
+		return x.FreqMode
+	}
+	return ""
+}
+
+func (x *Reading) GetRange() string {
+	if x != nil {
+		return x.Range
+	}
+	return ""
+}
+
+func (x *Reading) GetMaxHold() bool {
+	if x != nil {
+		return x.MaxHold
+	}
+	return false
+}
+
+func (x *Reading) GetOutOfRange() bool {
+	if x != nil {
+		return x.OutOfRange
+	}
+	return false
+}
+
+func (x *Reading) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *Reading) GetRaw() string {
+	if x != nil {
+		return x.Raw
+	}
+	return ""
+}
+
+func (x *Reading) GetLeq() float64 {
+	if x != nil {
+		return x.Leq
+	}
+	return 0
+}
+
+func (x *Reading) GetSmoothed() float64 {
+	if x != nil {
+		return x.Smoothed
+	}
+	return 0
+}
+
+func (x *Reading) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Reading) GetBatteryLow() bool {
+	if x != nil {
+		return x.BatteryLow
+	}
+	return false
+}
+
+func (x *Reading) GetUnknownConfig() bool {
+	if x != nil {
+		return x.UnknownConfig
+	}
+	return false
+}
+
+func (x *Reading) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+var File_decibel_proto protoreflect.FileDescriptor
+
+const file_decibel_proto_rawDesc = "" +
+	"\n" +
+	"\rdecibel.proto\x12\n" +
+	"decibel.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe2\x03\n" +
+	"\aReading\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\tR\ttimestamp\x12\x1a\n" +
+	"\bmeasured\x18\x03 \x01(\x01R\bmeasured\x12!\n" +
+	"\fraw_measured\x18\x04 \x01(\x01R\vrawMeasured\x12\x12\n" +
+	"\x04mode\x18\x05 \x01(\tR\x04mode\x12\x1b\n" +
+	"\tfreq_mode\x18\x06 \x01(\tR\bfreqMode\x12\x14\n" +
+	"\x05range\x18\a \x01(\tR\x05range\x12\x19\n" +
+	"\bmax_hold\x18\b \x01(\bR\amaxHold\x12 \n" +
+	"\fout_of_range\x18\t \x01(\bR\n" +
+	"outOfRange\x12\x16\n" +
+	"\x06serial\x18\n" +
+	" \x01(\tR\x06serial\x12\x10\n" +
+	"\x03raw\x18\v \x01(\tR\x03raw\x12\x10\n" +
+	"\x03leq\x18\f \x01(\x01R\x03leq\x12\x1a\n" +
+	"\bsmoothed\x18\r \x01(\x01R\bsmoothed\x12\x10\n" +
+	"\x03seq\x18\x0e \x01(\x04R\x03seq\x12\x1f\n" +
+	"\vbattery_low\x18\x0f \x01(\bR\n" +
+	"batteryLow\x12%\n" +
+	"\x0eunknown_config\x18\x10 \x01(\bR\runknownConfig\x12\x14\n" +
+	"\x05stale\x18\x11 \x01(\bR\x05stale2O\n" +
+	"\fDecibelMeter\x12?\n" +
+	"\x0eStreamReadings\x12\x16.google.protobuf.Empty\x1a\x13.decibel.v1.Reading0\x01B\x1dZ\x1busb-decibel-meter/decibelpbb\x06proto3"
+
+var (
+	file_decibel_proto_rawDescOnce sync.Once
+	file_decibel_proto_rawDescData []byte
+)
+
+func file_decibel_proto_rawDescGZIP() []byte {
+	file_decibel_proto_rawDescOnce.Do(func() {
+		file_decibel_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_decibel_proto_rawDesc), len(file_decibel_proto_rawDesc)))
+	})
+	return file_decibel_proto_rawDescData
+}
+
+var file_decibel_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_decibel_proto_goTypes = []any{
+	(*Reading)(nil),               // 0: decibel.v1.Reading
+	(*timestamppb.Timestamp)(nil), // 1: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 2: google.protobuf.Empty
+}
+var file_decibel_proto_depIdxs = []int32{
+	1, // 0: decibel.v1.Reading.time:type_name -> google.protobuf.Timestamp
+	2, // 1: decibel.v1.DecibelMeter.StreamReadings:input_type -> google.protobuf.Empty
+	0, // 2: decibel.v1.DecibelMeter.StreamReadings:output_type -> decibel.v1.Reading
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_decibel_proto_init() }
+func file_decibel_proto_init() {
+	if File_decibel_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_decibel_proto_rawDesc), len(file_decibel_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_decibel_proto_goTypes,
+		DependencyIndexes: file_decibel_proto_depIdxs,
+		MessageInfos:      file_decibel_proto_msgTypes,
+	}.Build()
+	File_decibel_proto = out.File
+	file_decibel_proto_goTypes = nil
+	file_decibel_proto_depIdxs = nil
+}
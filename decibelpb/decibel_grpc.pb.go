@@ -0,0 +1,131 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: decibel.proto
+
+package decibelpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DecibelMeter_StreamReadings_FullMethodName = "/decibel.v1.DecibelMeter/StreamReadings"
+)
+
+// DecibelMeterClient is the client API for DecibelMeter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DecibelMeter streams readings from a GM1356 sound level meter
+type DecibelMeterClient interface {
+	// StreamReadings sends every reading taken from now on until the client disconnects or the server shuts down
+	StreamReadings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Reading], error)
+}
+
+type decibelMeterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDecibelMeterClient(cc grpc.ClientConnInterface) DecibelMeterClient {
+	return &decibelMeterClient{cc}
+}
+
+func (c *decibelMeterClient) StreamReadings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Reading], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DecibelMeter_ServiceDesc.Streams[0], DecibelMeter_StreamReadings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, Reading]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DecibelMeter_StreamReadingsClient = grpc.ServerStreamingClient[Reading]
+
+// DecibelMeterServer is the server API for DecibelMeter service.
+// All implementations must embed UnimplementedDecibelMeterServer
+// for forward compatibility.
+//
+// DecibelMeter streams readings from a GM1356 sound level meter
+type DecibelMeterServer interface {
+	// StreamReadings sends every reading taken from now on until the client disconnects or the server shuts down
+	StreamReadings(*emptypb.Empty, grpc.ServerStreamingServer[Reading]) error
+	mustEmbedUnimplementedDecibelMeterServer()
+}
+
+// UnimplementedDecibelMeterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDecibelMeterServer struct{}
+
+func (UnimplementedDecibelMeterServer) StreamReadings(*emptypb.Empty, grpc.ServerStreamingServer[Reading]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamReadings not implemented")
+}
+func (UnimplementedDecibelMeterServer) mustEmbedUnimplementedDecibelMeterServer() {}
+func (UnimplementedDecibelMeterServer) testEmbeddedByValue()                      {}
+
+// UnsafeDecibelMeterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DecibelMeterServer will
+// result in compilation errors.
+type UnsafeDecibelMeterServer interface {
+	mustEmbedUnimplementedDecibelMeterServer()
+}
+
+func RegisterDecibelMeterServer(s grpc.ServiceRegistrar, srv DecibelMeterServer) {
+	// If the following call pancis, it indicates UnimplementedDecibelMeterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DecibelMeter_ServiceDesc, srv)
+}
+
+func _DecibelMeter_StreamReadings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DecibelMeterServer).StreamReadings(m, &grpc.GenericServerStream[emptypb.Empty, Reading]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DecibelMeter_StreamReadingsServer = grpc.ServerStreamingServer[Reading]
+
+// DecibelMeter_ServiceDesc is the grpc.ServiceDesc for DecibelMeter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DecibelMeter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "decibel.v1.DecibelMeter",
+	HandlerType: (*DecibelMeterServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamReadings",
+			Handler:       _DecibelMeter_StreamReadings_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "decibel.proto",
+}
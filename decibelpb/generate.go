@@ -0,0 +1,4 @@
+// Package decibelpb holds the gRPC service definition for streaming readings and its generated code.
+package decibelpb
+
+//go:generate buf generate
@@ -0,0 +1,16 @@
+package main
+
+// decimator passes one reading in every n to the outputs; a nil *decimator passes them all
+type decimator struct {
+	every int
+	seen  int
+}
+
+// keep counts a reading and reports whether it's the one in n to output, starting with the first
+func (d *decimator) keep() bool {
+	if d == nil {
+		return true
+	}
+	d.seen++
+	return (d.seen-1)%d.every == 0
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// errorRepeatWindow is how long identical errors are coalesced into one summary line
+const errorRepeatWindow = 10 * time.Second
+
+// errorLog logs failed reads without flooding the log during a disconnect: the first occurrence of an error is logged,
+// identical ones that follow are counted and summarized at most once per errorRepeatWindow, and the recovery is logged
+type errorLog struct {
+	logger     *slog.Logger
+	last       string    // Message of the last error logged, empty while reads succeed
+	firstAt    time.Time // When the current run of failures started
+	loggedAt   time.Time // When last was last logged or summarized
+	suppressed int       // Repeats of last not logged since loggedAt
+	failures   int       // Failures since firstAt
+}
+
+// newErrorLog creates an errorLog for the meter with the given serial, which is added to each line if set
+func newErrorLog(serial string) *errorLog {
+	logger := slog.Default()
+	if serial != "" {
+		logger = logger.With("serial", serial)
+	}
+	return &errorLog{logger: logger}
+}
+
+// failed logs err, or counts it if it repeats the previous error within the window
+func (l *errorLog) failed(now time.Time, err error) {
+	msg := err.Error()
+	if l.failures == 0 {
+		l.firstAt = now
+	}
+	l.failures++
+	if msg == l.last {
+		l.suppressed++
+		if now.Sub(l.loggedAt) < errorRepeatWindow {
+			return
+		}
+		l.logger.Error("Failed to read data", "err", err, "repeated", l.suppressed, "in", now.Sub(l.loggedAt).Round(time.Second))
+	} else {
+		l.flush(now)
+		l.logger.Error("Failed to read data", "err", err)
+	}
+	l.last, l.loggedAt, l.suppressed = msg, now, 0
+}
+
+// recovered logs the end of a run of failures, if there was one
+func (l *errorLog) recovered(now time.Time) {
+	if l.failures == 0 {
+		return
+	}
+	l.flush(now)
+	l.logger.Info("Reading recovered", "failures", l.failures, "after", now.Sub(l.firstAt).Round(time.Millisecond))
+	*l = errorLog{logger: l.logger}
+}
+
+// flush summarizes repeats of the last error that haven't been logged yet
+func (l *errorLog) flush(now time.Time) {
+	if l.suppressed > 0 {
+		l.logger.Error("Failed to read data", "err", l.last, "repeated", l.suppressed, "in", now.Sub(l.loggedAt).Round(time.Second))
+		l.suppressed = 0
+	}
+}
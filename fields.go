@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// readingFieldIndex maps the JSON name of each DecibelReading field --fields can select to its struct field
+var readingFieldIndex = map[string]int{}
+
+// readingFieldNames lists the selectable fields in struct order, for error messages
+var readingFieldNames []string
+
+// readingFieldOmitted holds the fields JSON leaves out when unset, which are left blank in CSV likewise
+var readingFieldOmitted = map[string]bool{}
+
+func init() {
+	t := reflect.TypeFor[gm1356.DecibelReading]()
+	for i := range t.NumField() {
+		name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		readingFieldIndex[name] = i
+		readingFieldOmitted[name] = options == "omitempty"
+		readingFieldNames = append(readingFieldNames, name)
+	}
+}
+
+// metadataKeys are the JSON keys metadata adds to a record; they're kept after the selected fields
+var metadataKeys = []string{"location", "hostname", "tags"}
+
+// parseFields parses a --fields list such as "timestamp,measured,range", rejecting unknown and repeated names
+func parseFields(s string) ([]string, error) {
+	var selected []string
+	for name := range strings.SplitSeq(s, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := readingFieldIndex[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q (valid choices: %s)", name, strings.Join(readingFieldNames, ", "))
+		}
+		if slices.Contains(selected, name) {
+			return nil, fmt.Errorf("field %q is listed twice", name)
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+// selectJSON rewrites an encoded record with only the selected keys, in the selected order, followed by any metadata; keys the record left out, such as an unset leq, stay out
+func selectJSON(record []byte, selected []string) ([]byte, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(record, &values); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for _, key := range slices.Concat(selected, metadataKeys) {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// fieldValues renders the selected fields of a reading as CSV values, with levels at --precision and nested values such as estimate as JSON
+func fieldValues(data gm1356.DecibelReading, selected []string) []string {
+	v := reflect.ValueOf(data)
+	values := make([]string, len(selected))
+	for i, name := range selected {
+		field := v.Field(readingFieldIndex[name])
+		if readingFieldOmitted[name] && field.IsZero() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Float64:
+			values[i] = formatLevel(field.Float())
+		case reflect.String:
+			values[i] = field.String()
+		case reflect.Bool:
+			values[i] = strconv.FormatBool(field.Bool())
+		default:
+			encoded, _ := json.Marshal(field.Interface())
+			values[i] = string(encoded)
+		}
+	}
+	return values
+}
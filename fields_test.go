@@ -0,0 +1,88 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{"timestamp,measured,range", []string{"timestamp", "measured", "range"}, false},
+		{"measured, timestamp", []string{"measured", "timestamp"}, false},
+		{"seq,outOfRange,estimate", []string{"seq", "outOfRange", "estimate"}, false},
+		{"measured,volume", nil, true},
+		{"measured,measured", nil, true},
+		{"time", nil, true}, // DecibelReading.Time isn't encoded, so it can't be selected
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseFields(tt.list)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("parseFields(%q) = %q, %v, want %q, wantErr %t", tt.list, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSelectJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   string
+		selected []string
+		want     string
+	}{
+		{"reordered", `{"timestamp":"t","measured":42.1,"mode":"slow"}`, []string{"measured", "timestamp"}, `{"measured":42.1,"timestamp":"t"}`},
+		{"omitted key stays out", `{"measured":42.1}`, []string{"measured", "leq"}, `{"measured":42.1}`},
+		{"metadata kept last", `{"measured":42.1,"location":"kitchen","mode":"slow"}`, []string{"mode"}, `{"mode":"slow","location":"kitchen"}`},
+		{"nested value", `{"estimate":{"freqMode":"dBC","measured":44.1,"estimated":true}}`, []string{"estimate"}, `{"estimate":{"freqMode":"dBC","measured":44.1,"estimated":true}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectJSON([]byte(tt.record), tt.selected)
+			if err != nil {
+				t.Fatalf("selectJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("selectJSON(%s, %q) = %s, want %s", tt.record, tt.selected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldValues(t *testing.T) {
+	defer func(saved int) { precision = saved }(precision)
+	precision = 1
+
+	data := gm1356.DecibelReading{
+		Time:       time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC),
+		Timestamp:  "2025-03-01 05:04:00 UTC",
+		Measured:   42,
+		Mode:       "slow",
+		OutOfRange: true,
+		Seq:        7,
+		Estimate:   &gm1356.WeightingEstimate{FreqMode: "dBC", Measured: 44.1, Estimated: true},
+	}
+	tests := []struct {
+		name     string
+		selected []string
+		want     []string
+	}{
+		{"levels at precision", []string{"measured", "timestamp"}, []string{"42.0", "2025-03-01 05:04:00 UTC"}},
+		{"bools", []string{"maxHold", "outOfRange"}, []string{"false", "true"}},
+		{"unset omitempty fields are blank", []string{"leq", "serial", "stale", "mode"}, []string{"", "", "", "slow"}},
+		{"numbers and nested values", []string{"seq", "estimate"}, []string{"7", `{"freqMode":"dBC","measured":44.1,"estimated":true}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fieldValues(data, tt.selected); !slices.Equal(got, tt.want) {
+				t.Errorf("fieldValues(%q) = %q, want %q", tt.selected, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "time"
+
+// flushPolicy says when buffered rows are written out: after interval, after rows rows, or both, whichever comes first
+type flushPolicy struct {
+	interval time.Duration // 0 disables time-based flushing
+	rows     int           // 0 disables count-based flushing
+}
+
+// isSet reports whether a limit was configured; without one every row is written out right away
+func (p flushPolicy) isSet() bool {
+	return p.interval > 0 || p.rows > 0
+}
+
+// due reports whether pending buffered rows, the last of them flushed at last, should be written out now
+func (p flushPolicy) due(pending int, last time.Time) bool {
+	if !p.isSet() {
+		return true
+	}
+	return (p.rows > 0 && pending >= p.rows) || (p.interval > 0 && time.Since(last) >= p.interval)
+}
+
+// withDefaults fills the limits p leaves unset from def, for writers that always batch
+func (p flushPolicy) withDefaults(def flushPolicy) flushPolicy {
+	if p.interval == 0 {
+		p.interval = def.interval
+	}
+	if p.rows == 0 {
+		p.rows = def.rows
+	}
+	return p
+}
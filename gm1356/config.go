@@ -0,0 +1,73 @@
+package gm1356
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Config byte bit masks
+const (
+	RangeMask  = 0x07 // Bits 0-2; bit 3 is BatteryLowBit
+	DBCBit     = 0x10
+	MaxHoldBit = 0x20
+	FastBit    = 0x40
+
+	// BatteryLowBit is an unconfirmed battery-low flag, only decoded on request; see ParseBatteryLow
+	BatteryLowBit = 0x08
+)
+
+// Range mapping based on the C code definition
+var RangeMap = map[byte]string{
+	0x0: "30-130",
+	0x1: "30-80",
+	0x2: "50-100",
+	0x3: "60-110",
+	0x4: "80-130",
+}
+
+// Settings describes a config change; zero-valued fields keep the device's current setting
+type Settings struct {
+	Range    string // e.g. "50-100"
+	FreqMode string // "dBA" or "dBC"
+	Fast     *bool  // true for fast response, false for slow
+	MaxHold  *bool  // true to hold the peak level on the display
+}
+
+// Empty reports whether no config change was requested
+func (s Settings) Empty() bool {
+	return s.Range == "" && s.FreqMode == "" && s.Fast == nil && s.MaxHold == nil
+}
+
+// Validate checks that the requested settings are supported by the GM1356
+func (s Settings) Validate() error {
+	return ProfileGM1356.Validate(s)
+}
+
+// Apply merges the requested changes into the given GM1356 config byte, keeping all other bits as they are
+func (s Settings) Apply(config byte) (byte, error) {
+	return ProfileGM1356.Apply(s, config)
+}
+
+// LookupRange reverse-looks-up the GM1356 range bits for a range string such as "50-100"
+func LookupRange(value string) (byte, error) {
+	return ProfileGM1356.LookupRange(value)
+}
+
+// RangeBounds returns the lower and upper level in dB of a range string such as "50-100"
+func RangeBounds(rangeStr string) (low, high float64, ok bool) {
+	lowStr, highStr, found := strings.Cut(rangeStr, "-")
+	if !found {
+		return 0, 0, false
+	}
+	low, errLow := strconv.ParseFloat(lowStr, 64)
+	high, errHigh := strconv.ParseFloat(highStr, 64)
+	if errLow != nil || errHigh != nil {
+		return 0, 0, false
+	}
+	return low, high, true
+}
+
+// ValidRanges lists the range strings from RangeMap in config value order
+func ValidRanges() []string {
+	return ProfileGM1356.ValidRanges()
+}
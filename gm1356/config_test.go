@@ -0,0 +1,87 @@
+package gm1356
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSettingsApply(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name     string
+		settings Settings
+		config   byte
+		want     byte
+	}{
+		{"empty keeps config", Settings{}, 0x52, 0x52},
+		{"range only", Settings{Range: "80-130"}, 0x52, 0x54},
+		{"range keeps flags", Settings{Range: "30-130"}, 0xF3, 0xF0},
+		{"dBC", Settings{FreqMode: "dBC"}, 0x00, 0x10},
+		{"dBA", Settings{FreqMode: "dBA"}, 0x12, 0x02},
+		{"dBA clears the other dBC bit", Settings{FreqMode: "dBA"}, 0x92, 0x02},
+		{"weighting is case-insensitive", Settings{FreqMode: "dbc"}, 0x00, 0x10},
+		{"fast", Settings{Fast: &on}, 0x01, 0x41},
+		{"slow", Settings{Fast: &off}, 0x41, 0x01},
+		{"max hold", Settings{MaxHold: &on}, 0x00, 0x20},
+		{"all at once", Settings{Range: "60-110", FreqMode: "dBC", Fast: &on, MaxHold: &off}, 0x20, 0x53},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.settings.Apply(tt.config)
+			if err != nil {
+				t.Fatalf("Apply(%#02x) error = %v", tt.config, err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply(%#02x) = %#02x, want %#02x", tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		settings Settings
+		wantErr  bool
+	}{
+		{Settings{}, false},
+		{Settings{Range: "50-100", FreqMode: "dBA"}, false},
+		{Settings{Range: "40-90"}, true},
+		{Settings{FreqMode: "dBZ"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.settings.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, wantErr %t", tt.settings, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLookupRange(t *testing.T) {
+	for nibble, rangeStr := range RangeMap {
+		got, err := LookupRange(rangeStr)
+		if err != nil || got != nibble {
+			t.Errorf("LookupRange(%q) = %#x, %v, want %#x", rangeStr, got, err, nibble)
+		}
+	}
+	if _, err := LookupRange("unknown"); err == nil {
+		t.Error("LookupRange(\"unknown\") succeeded, want error")
+	}
+}
+
+func TestValidRanges(t *testing.T) {
+	want := []string{"30-130", "30-80", "50-100", "60-110", "80-130"}
+	if got := ValidRanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidRanges() = %v, want %v", got, want)
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	for _, rangeStr := range ValidRanges() {
+		low, high, ok := RangeBounds(rangeStr)
+		if !ok || low >= high {
+			t.Errorf("RangeBounds(%q) = %v, %v, %t", rangeStr, low, high, ok)
+		}
+	}
+	if _, _, ok := RangeBounds("unknown"); ok {
+		t.Error("RangeBounds(\"unknown\") ok = true, want false")
+	}
+}
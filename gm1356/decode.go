@@ -0,0 +1,45 @@
+package gm1356
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecodeVariant selects how the two level bytes of a packet are decoded, for firmware that differs from the reference implementation
+type DecodeVariant string
+
+// Decode variants
+const (
+	VariantStandard     DecodeVariant = "standard"      // Big-endian tenths of a dB, as in the reference C implementation
+	VariantMasked       DecodeVariant = "masked"        // As standard, ignoring status flags in the top bits of the high byte
+	VariantLittleEndian DecodeVariant = "little-endian" // Tenths of a dB with the low byte first
+)
+
+// levelHighMask keeps the bits of the high level byte needed for up to 204.7 dB, well above the meter's 130 dB limit
+const levelHighMask = 0x07
+
+// DecodeVariants lists the variants accepted by ParseDecodeVariant
+func DecodeVariants() []string {
+	return []string{string(VariantStandard), string(VariantMasked), string(VariantLittleEndian)}
+}
+
+// ParseDecodeVariant looks up a decode variant by name
+func ParseDecodeVariant(name string) (DecodeVariant, error) {
+	switch variant := DecodeVariant(name); variant {
+	case VariantStandard, VariantMasked, VariantLittleEndian:
+		return variant, nil
+	}
+	return "", fmt.Errorf("unknown decode variant %q (valid choices: %s)", name, strings.Join(DecodeVariants(), ", "))
+}
+
+// decodeLevel decodes the level in dB from the first two bytes of a packet; the zero variant is VariantStandard
+func decodeLevel(buf []byte, variant DecodeVariant) float64 {
+	high, low := buf[0], buf[1]
+	switch variant {
+	case VariantMasked:
+		high &= levelHighMask
+	case VariantLittleEndian:
+		high, low = low, high
+	}
+	return float64(uint16(high)<<8|uint16(low)) / 10.0
+}
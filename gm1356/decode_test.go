@@ -0,0 +1,38 @@
+package gm1356
+
+import "testing"
+
+func TestDecodeLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		variant DecodeVariant
+		want    float64
+	}{
+		{"standard", []byte{0x02, 0x8D}, VariantStandard, 65.3},
+		{"zero variant is standard", []byte{0x02, 0x8D}, "", 65.3},
+		{"standard 130 dB", []byte{0x05, 0x14}, VariantStandard, 130.0},
+		{"standard keeps flag bits", []byte{0x82, 0x8D}, VariantStandard, 3342.1},
+		{"masked drops flag bits", []byte{0x82, 0x8D}, VariantMasked, 65.3},
+		{"masked plain level", []byte{0x05, 0x14}, VariantMasked, 130.0},
+		{"little-endian", []byte{0x8D, 0x02}, VariantLittleEndian, 65.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeLevel(tt.buf, tt.variant); got != tt.want {
+				t.Errorf("decodeLevel(%X, %q) = %v, want %v", tt.buf, tt.variant, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDecodeVariant(t *testing.T) {
+	for _, name := range DecodeVariants() {
+		if variant, err := ParseDecodeVariant(name); err != nil || string(variant) != name {
+			t.Errorf("ParseDecodeVariant(%q) = %q, %v", name, variant, err)
+		}
+	}
+	if _, err := ParseDecodeVariant("big-endian"); err == nil {
+		t.Error("ParseDecodeVariant(\"big-endian\") error = nil, want error")
+	}
+}
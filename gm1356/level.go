@@ -0,0 +1,38 @@
+package gm1356
+
+import "math"
+
+// Energy converts a level in dB to relative acoustic energy
+func Energy(level float64) float64 {
+	return math.Pow(10, level/10)
+}
+
+// Level converts relative acoustic energy back to a level in dB
+func Level(energy float64) float64 {
+	return 10 * math.Log10(energy)
+}
+
+// EnergyMean returns the mean relative energy of levels in dB, or 0 if there are none
+func EnergyMean(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, level := range samples {
+		sum += Energy(level)
+	}
+	return sum / float64(len(samples))
+}
+
+// Leq returns the equivalent continuous level of samples in dB, averaging energy rather than decibels, or 0 if there are none
+func Leq(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	return Level(EnergyMean(samples))
+}
+
+// AddDB returns the combined level of two incoherent sources, e.g. two 60 dB sources make 63 dB
+func AddDB(a, b float64) float64 {
+	return Level(Energy(a) + Energy(b))
+}
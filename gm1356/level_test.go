@@ -0,0 +1,73 @@
+package gm1356
+
+import (
+	"math"
+	"testing"
+)
+
+// roughly reports whether a and b agree to 0.01 dB
+func roughly(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestEnergyLevelRoundTrip(t *testing.T) {
+	for _, level := range []float64{0, 30, 65.3, 130} {
+		if got := Level(Energy(level)); !roughly(got, level) {
+			t.Errorf("Level(Energy(%v)) = %v", level, got)
+		}
+	}
+	if got := Energy(10); got != 10 {
+		t.Errorf("Energy(10) = %v, want 10", got)
+	}
+}
+
+func TestAddDB(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b float64
+		want float64
+	}{
+		{"two equal sources", 60, 60, 63.01},
+		{"two equal loud sources", 90, 90, 93.01},
+		{"10 dB quieter source", 70, 60, 70.41},
+		{"order doesn't matter", 60, 70, 70.41},
+		{"much quieter source", 80, 40, 80.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddDB(tt.a, tt.b); !roughly(got, tt.want) {
+				t.Errorf("AddDB(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeq(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []float64
+		want    float64
+	}{
+		{"empty", nil, 0},
+		{"single", []float64{65.3}, 65.3},
+		{"constant", []float64{70, 70, 70}, 70},
+		{"loud half dominates", []float64{60, 80}, 77.03},
+		{"short burst", []float64{40, 40, 40, 100}, 93.98},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Leq(tt.samples); !roughly(got, tt.want) {
+				t.Errorf("Leq(%v) = %v, want %v", tt.samples, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnergyMean(t *testing.T) {
+	if got := EnergyMean(nil); got != 0 {
+		t.Errorf("EnergyMean(nil) = %v, want 0", got)
+	}
+	if got := EnergyMean([]float64{10, 20}); got != 55 {
+		t.Errorf("EnergyMean([10 20]) = %v, want 55", got)
+	}
+}
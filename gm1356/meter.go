@@ -0,0 +1,501 @@
+// Package gm1356 reads decibel measurements from GM1356 and compatible USB sound level meters over HID.
+package gm1356
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"time"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+// Device Info for GM1356
+const (
+	VendorID  = 25789 // 0x64bd
+	ProductID = 29923 // 0x74e3
+)
+
+// GM1356 Commands (must be 8 bytes)
+var (
+	CommandCapture = []byte{0xB3, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00} // Capture measurement
+)
+
+// CommandConfigure is the first byte of the config command; the second byte carries the config byte
+const CommandConfigure = 0x56
+
+// Errors returned by Meter methods and ParseDecibelData
+var (
+	ErrNoData      = errors.New("no data read from device")         // Device answered with an empty packet
+	ErrClosed      = errors.New("device is closed")                 // Handle was closed, e.g. after a failed Reopen
+	ErrShortPacket = errors.New("truncated packet")                 // Packet too short to decode
+	ErrTimeout     = errors.New("timed out reading device")         // No packet arrived within ReadTimeout
+	ErrShortWrite  = errors.New("short write to device")            // Device kept accepting fewer than 8 command bytes
+	ErrPermission  = errors.New("permission denied")                // Device is attached but the user isn't allowed to open it
+	ErrNotAccepted = errors.New("device didn't accept the setting") // Config read back still differs after resending the command
+)
+
+// Retries for ReadConfig, which often fails once right after the device is opened
+const (
+	configReadAttempts = 3
+	configRetryDelay   = 100 * time.Millisecond
+)
+
+// DefaultCommandDelay is how long the device is given to process a command that changes its settings
+const DefaultCommandDelay = 500 * time.Millisecond
+
+// writeAttempts bounds how often a command is resent after a short write
+const writeAttempts = 3
+
+// configWriteAttempts is how often SetConfig sends the config command before giving up on the device accepting it
+const configWriteAttempts = 2
+
+// DefaultReadTimeout is how long a read waits for the device to answer before failing with ErrTimeout
+const DefaultReadTimeout = 2 * time.Second
+
+// readSlice is how long each underlying HID read waits before checking whether the context was cancelled
+const readSlice = 100 * time.Millisecond
+
+// Meter is an open connection to a GM1356 sound level meter
+type Meter struct {
+	device     *hid.Device
+	vendorID   uint16     // USB vendor ID the device is opened with
+	productID  uint16     // USB product ID the device is opened with
+	wantSerial string     // Serial number requested at open time, empty for the first device found
+	info       DeviceInfo // Identification strings reported by the open device
+
+	streaming bool   // Capture command sent and the device is streaming packets
+	streamed  int    // Packets read since the capture command was last sent
+	noStream  bool   // Device never streamed, so every read is commanded
+	last      []byte // Previous measurement packet, to flag repeats as stale
+
+	// CommandDelay is the settle time after a config command; it is a device-processing requirement, not a sampling rate
+	CommandDelay time.Duration
+
+	// PollDelay is the settle time after the read-only capture command; the zero value reads straight away, as the read
+	// itself waits up to ReadTimeout for the answer
+	PollDelay time.Duration
+
+	// ReadTimeout bounds each read so a hung device returns ErrTimeout instead of blocking forever; 0 blocks
+	ReadTimeout time.Duration
+
+	// Calibration is an offset in dB added to every reading; being in the log domain it is a plain addition
+	Calibration float64
+
+	// Now, if set, is the clock used to timestamp readings; it defaults to the current time in UTC
+	Now func() time.Time
+
+	// Profile describes the model's commands and config byte layout; nil is ProfileGM1356
+	Profile *DeviceProfile
+
+	// Variant selects how the level bytes are decoded; the zero value is VariantStandard
+	Variant DecodeVariant
+
+	// DecodeBattery, if set, decodes the unconfirmed battery-low bit into DecibelReading.BatteryLow (see ParseBatteryLow)
+	DecodeBattery bool
+
+	// Streaming, if set, sends the capture command once and then reads the packets the device keeps sending, which roughly
+	// doubles the sample rate on units that stream in measurement mode; it falls back to commanding every read if they dry up
+	Streaming bool
+
+	// RecaptureEvery, if non-zero, resends the capture command after this many streamed reads
+	RecaptureEvery int
+
+	// IncludeRaw, if set, stores the hex-encoded packet behind each reading in DecibelReading.Raw
+	IncludeRaw bool
+
+	// Logger, if set, receives debug output such as sent commands and raw packets
+	Logger *slog.Logger
+}
+
+// Init initializes the underlying HIDAPI library
+func Init() error {
+	return hid.Init()
+}
+
+// Exit releases the resources held by the underlying HIDAPI library
+func Exit() error {
+	return hid.Exit()
+}
+
+// Open opens the first GM1356 attached to the system
+func Open() (*Meter, error) {
+	return OpenSerial("")
+}
+
+// OpenSerial opens the GM1356 with the given serial number; an empty serial opens the first one found
+func OpenSerial(serial string) (*Meter, error) {
+	return OpenDevice(VendorID, ProductID, serial)
+}
+
+// OpenDevice opens a meter speaking the GM1356 protocol under other USB IDs, such as a rebranded clone; an empty serial opens the first one found
+func OpenDevice(vendorID, productID uint16, serial string) (*Meter, error) {
+	m := &Meter{vendorID: vendorID, productID: productID, wantSerial: serial, CommandDelay: DefaultCommandDelay, ReadTimeout: DefaultReadTimeout}
+	if err := m.open(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// open opens the device selected at construction time
+func (m *Meter) open() error {
+	var device *hid.Device
+	var err error
+	if m.wantSerial == "" {
+		device, err = hid.OpenFirst(m.vendorID, m.productID)
+	} else {
+		device, err = openSerial(m.vendorID, m.productID, m.wantSerial)
+	}
+	if err != nil {
+		if isPermissionError(err) {
+			return fmt.Errorf("%w: %v", ErrPermission, err)
+		}
+		return err
+	}
+
+	m.device = device
+	m.info = readDeviceInfo(device)
+	m.streaming, m.streamed = false, 0
+	m.last = m.last[:0]
+	return nil
+}
+
+// isPermissionError reports whether an open error means access was denied; HIDAPI only reports a message, which differs
+// per platform: strerror(EACCES) from hidraw on Linux, an IOKit "not permitted" on macOS, and "Access is denied" on Windows
+func isPermissionError(err error) bool {
+	if errors.Is(err, fs.ErrPermission) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "permission denied") || strings.Contains(msg, "not permitted") || strings.Contains(msg, "access is denied")
+}
+
+// DeviceInfo identifies an open meter by the strings it reports over USB
+type DeviceInfo struct {
+	Manufacturer string `json:"manufacturer"`
+	Product      string `json:"product"`
+	Serial       string `json:"serial"`
+	Release      string `json:"release,omitempty"` // Device release (firmware) number, e.g. "1.00"
+}
+
+// AttachedDevice is a HID device found by Enumerate
+type AttachedDevice struct {
+	Path      string
+	VendorID  uint16
+	ProductID uint16
+	DeviceInfo
+}
+
+// Enumerate lists the attached HID devices with the given IDs; a zero vendor ID lists every HID device
+func Enumerate(vendorID, productID uint16) ([]AttachedDevice, error) {
+	if vendorID == 0 {
+		productID = 0
+	}
+	var devices []AttachedDevice
+	err := hid.Enumerate(vendorID, productID, func(info *hid.DeviceInfo) error {
+		devices = append(devices, AttachedDevice{
+			Path:       info.Path,
+			VendorID:   info.VendorID,
+			ProductID:  info.ProductID,
+			DeviceInfo: DeviceInfo{Manufacturer: info.MfrStr, Product: info.ProductStr, Serial: info.SerialNbr, Release: formatRelease(info.ReleaseNbr)},
+		})
+		return nil
+	})
+	return devices, err
+}
+
+// formatRelease formats a BCD device release number such as 0x0100 as "1.00"
+func formatRelease(bcd uint16) string {
+	return fmt.Sprintf("%x.%02x", bcd>>8, bcd&0xFF)
+}
+
+// readDeviceInfo queries the identification strings of an open device, falling back to the individual getters on older HIDAPI versions
+func readDeviceInfo(device *hid.Device) DeviceInfo {
+	if info, err := device.GetDeviceInfo(); err == nil {
+		return DeviceInfo{
+			Manufacturer: info.MfrStr,
+			Product:      info.ProductStr,
+			Serial:       info.SerialNbr,
+			Release:      formatRelease(info.ReleaseNbr),
+		}
+	}
+
+	var info DeviceInfo
+	info.Manufacturer, _ = device.GetMfrStr()
+	info.Product, _ = device.GetProductStr()
+	info.Serial, _ = device.GetSerialNbr()
+	return info
+}
+
+// openSerial enumerates matching devices and opens the one with the given serial number
+func openSerial(vendorID, productID uint16, serial string) (*hid.Device, error) {
+	var path string
+	var available []string
+	seen := map[string]bool{}
+	hid.Enumerate(vendorID, productID, func(info *hid.DeviceInfo) error {
+		if info.SerialNbr == serial && path == "" {
+			path = info.Path
+		}
+		if !seen[info.SerialNbr] {
+			seen[info.SerialNbr] = true
+			available = append(available, info.SerialNbr)
+		}
+		return nil
+	})
+
+	if path == "" {
+		if len(available) == 0 {
+			return nil, fmt.Errorf("no device with serial %q found (no matching devices attached)", serial)
+		}
+		return nil, fmt.Errorf("no device with serial %q found (available serials: %s)", serial, strings.Join(available, ", "))
+	}
+	return hid.OpenPath(path)
+}
+
+// Serial returns the serial number reported by the device, if any
+func (m *Meter) Serial() string {
+	return m.info.Serial
+}
+
+// Info returns the manufacturer, product, and serial strings reported by the device
+func (m *Meter) Info() DeviceInfo {
+	return m.info
+}
+
+// Close closes the device handle
+func (m *Meter) Close() error {
+	if m.device == nil {
+		return nil
+	}
+	err := m.device.Close()
+	m.device = nil
+	return err
+}
+
+// Reopen closes the current device handle and opens the same device again
+func (m *Meter) Reopen() error {
+	m.Close()
+	return m.open()
+}
+
+// Read requests a measurement from the device and decodes it, returning ctx's error promptly if it is cancelled
+func (m *Meter) Read(ctx context.Context) (DecibelReading, error) {
+	buf := make([]byte, 8)
+	n, err := m.capture(ctx, buf)
+	if err != nil {
+		return DecibelReading{}, err
+	}
+	if n == 0 {
+		return DecibelReading{}, ErrNoData
+	}
+
+	m.debug("Raw data read", "bytes", n, "data", fmt.Sprintf("%v", buf[:n]))
+	reading, err := m.Profile.Decode(buf[:n], m.now(), m.Variant, m.DecodeBattery, m.Calibration)
+	if err != nil {
+		return DecibelReading{}, err
+	}
+	reading.Serial = m.info.Serial
+	reading.Stale = bytes.Equal(buf[:n], m.last)
+	m.last = append(m.last[:0], buf[:n]...)
+	if m.IncludeRaw {
+		reading.Raw = hex.EncodeToString(buf[:n])
+	}
+	return reading, nil
+}
+
+// capture reads one measurement packet into buf, sending the capture command first unless the device is already streaming
+func (m *Meter) capture(ctx context.Context, buf []byte) (int, error) {
+	if m.Streaming && m.streaming && !m.noStream && (m.RecaptureEvery == 0 || m.streamed < m.RecaptureEvery) {
+		n, err := m.read(ctx, buf)
+		if err == nil && n > 0 {
+			m.streamed++
+			return n, nil
+		}
+		if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+			return 0, fmt.Errorf("failed to read data: %w", err)
+		}
+		if m.streamed == 0 {
+			m.noStream = true
+			if m.Logger != nil {
+				m.Logger.Warn("Device does not stream, sending the capture command before every read")
+			}
+		} else {
+			m.debug("Stream dried up, resending capture command", "streamed", m.streamed, "err", err)
+		}
+		m.streaming = false
+	}
+
+	// Send capture command before reading data
+	if err := m.sendCommand(ctx, m.Profile.orDefault().CaptureCommand, m.PollDelay); err != nil {
+		return 0, fmt.Errorf("failed to send capture command: %w", err)
+	}
+
+	// Read HID response
+	n, err := m.read(ctx, buf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read data: %w", err)
+	}
+	m.streaming, m.streamed = n > 0, 0
+	return n, nil
+}
+
+// ReadConfig reads a single packet from the device and returns its config byte (mode, frequency mode, and range).
+// The first read after opening often fails with an I/O error on Linux, so it is retried a few times before giving up.
+func (m *Meter) ReadConfig(ctx context.Context) (byte, error) {
+	var err error
+	for attempt := 1; attempt <= configReadAttempts; attempt++ {
+		var config byte
+		if config, err = m.readConfig(ctx); err == nil || errors.Is(err, ErrClosed) || ctx.Err() != nil {
+			return config, err
+		}
+		if attempt < configReadAttempts {
+			m.debug("Config read failed, retrying", "attempt", attempt, "err", err)
+			if err := sleep(ctx, configRetryDelay); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return 0, err
+}
+
+// readConfig makes a single attempt at reading the config byte
+func (m *Meter) readConfig(ctx context.Context) (byte, error) {
+	buf := make([]byte, 8)
+
+	// Send capture command to request a data sample
+	if err := m.sendCommand(ctx, m.Profile.orDefault().CaptureCommand, m.PollDelay); err != nil {
+		return 0, fmt.Errorf("failed to send initial capture command: %w", err)
+	}
+
+	// Read one data packet from the device
+	n, err := m.read(ctx, buf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read initial data: %w", err)
+	}
+	if n < 6 {
+		return 0, fmt.Errorf("failed to read initial data: %w (got %d bytes, need 6)", ErrShortPacket, n)
+	}
+
+	return buf[2], nil
+}
+
+// SetConfig applies the requested settings in a single config write and returns the config byte read back afterwards, failing with ErrNotAccepted if it still doesn't match after a resend
+func (m *Meter) SetConfig(ctx context.Context, settings Settings) (byte, error) {
+	current, err := m.ReadConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	profile := m.Profile.orDefault()
+	config, err := profile.Apply(settings, current)
+	if err != nil {
+		return 0, err
+	}
+
+	// Read back the config byte to confirm the change took effect, resending once since some firmware ignores a write
+	var missing []string
+	for attempt := 1; attempt <= configWriteAttempts; attempt++ {
+		if err := m.sendCommand(ctx, profile.BuildConfigCommand(config), m.CommandDelay); err != nil {
+			return 0, fmt.Errorf("failed to send config command: %w", err)
+		}
+		if current, err = m.ReadConfig(ctx); err != nil {
+			return 0, err
+		}
+		if missing = profile.Unapplied(settings, current); len(missing) == 0 {
+			return current, nil
+		}
+		m.debug("Config read back doesn't match the request", "attempt", attempt, "sent", fmt.Sprintf("%#02x", config), "read", fmt.Sprintf("%#02x", current))
+	}
+	return current, fmt.Errorf("%w: %s", ErrNotAccepted, strings.Join(missing, ", "))
+}
+
+// BuildConfigCommand builds the 8-byte GM1356 config command carrying the given config byte
+func BuildConfigCommand(config byte) []byte {
+	return ProfileGM1356.BuildConfigCommand(config)
+}
+
+// read reads one packet, waiting at most ReadTimeout; it waits in slices of readSlice so a cancelled ctx ends the wait promptly
+func (m *Meter) read(ctx context.Context, buf []byte) (int, error) {
+	if m.device == nil {
+		return 0, ErrClosed
+	}
+	var deadline time.Time
+	if m.ReadTimeout > 0 {
+		deadline = time.Now().Add(m.ReadTimeout)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		wait := readSlice
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return 0, ErrTimeout
+			}
+			wait = min(wait, remaining)
+		}
+		n, err := m.device.ReadWithTimeout(buf, wait)
+		if !errors.Is(err, hid.ErrTimeout) {
+			return n, err
+		}
+	}
+}
+
+// sendCommand sends an 8-byte command to the GM1356, resending it after a short write, and waits settle for the device to process it
+func (m *Meter) sendCommand(ctx context.Context, command []byte, settle time.Duration) error {
+	if m.device == nil {
+		return ErrClosed
+	}
+	var n int
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		var err error
+		if n, err = m.device.Write(command); err != nil {
+			return fmt.Errorf("failed to send command (sent %d bytes): %v", n, err)
+		}
+		if n == len(command) {
+			break
+		}
+		m.debug("Short command write, retrying", "attempt", attempt, "sent", n)
+	}
+	if n != len(command) {
+		return fmt.Errorf("%w (sent %d of %d bytes after %d attempts)", ErrShortWrite, n, len(command), writeAttempts)
+	}
+	m.debug("Command sent", "command", fmt.Sprintf("%X", command))
+	return sleep(ctx, settle) // Wait for device to process command
+}
+
+// sleep waits for d, returning ctx's error early if it is cancelled first
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// now returns the current time from Now, or UTC time if no clock was injected
+func (m *Meter) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return utcNow()
+}
+
+// debug forwards debug output to Logger if it is set
+func (m *Meter) debug(msg string, args ...any) {
+	if m.Logger != nil {
+		m.Logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,27 @@
+package gm1356
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestIsPermissionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("Failed to open a device with path '/dev/hidraw3': Permission denied"), true},
+		{errors.New("IOHIDDeviceOpen failed: (0xE00002E2) (iokit/common) not permitted"), true},
+		{errors.New("hid_open_path: failed to open device: Access is denied."), true},
+		{fmt.Errorf("open: %w", fs.ErrPermission), true},
+		{errors.New("hidapi: device not found"), false},
+		{errors.New("Failed to open a device with path '/dev/hidraw3': No such file or directory"), false},
+	}
+	for _, tt := range tests {
+		if got := isPermissionError(tt.err); got != tt.want {
+			t.Errorf("isPermissionError(%q) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package gm1356
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DeviceProfile describes the parts of the protocol that differ between models of the GM1356 family and their clones,
+// so supporting a new model is a matter of adding a profile
+type DeviceProfile struct {
+	Name      string
+	VendorID  uint16
+	ProductID uint16
+
+	CaptureCommand []byte // 8-byte command requesting a measurement
+	ConfigOpcode   byte   // First byte of the config command; the second byte carries the config byte
+
+	// Config byte layout
+	RangeMask     byte // Bits holding the range, looked up in RangeMap
+	DBCBit        byte // Bit set to select dBC
+	DBCMask       byte // Bits any of which decode as dBC
+	MaxHoldBit    byte
+	FastBit       byte
+	BatteryLowBit byte // Unconfirmed battery-low flag, see ParseBatteryLow
+
+	RangeMap map[byte]string // Range value (after RangeMask) to range string such as "50-100"
+}
+
+// ProfileGM1356 is the GM1356 as documented by the reference C implementation
+var ProfileGM1356 = DeviceProfile{
+	Name:           "gm1356",
+	VendorID:       VendorID,
+	ProductID:      ProductID,
+	CaptureCommand: CommandCapture,
+	ConfigOpcode:   CommandConfigure,
+	RangeMask:      RangeMask,
+	DBCBit:         DBCBit,
+	DBCMask:        DBCBit | 0x80,
+	MaxHoldBit:     MaxHoldBit,
+	FastBit:        FastBit,
+	BatteryLowBit:  BatteryLowBit,
+	RangeMap:       RangeMap,
+}
+
+// profiles lists the built-in profiles accepted by LookupProfile
+var profiles = []*DeviceProfile{&ProfileGM1356}
+
+// Profiles lists the names of the built-in device profiles
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// LookupProfile returns the built-in device profile with the given name
+func LookupProfile(name string) (*DeviceProfile, error) {
+	i := slices.IndexFunc(profiles, func(p *DeviceProfile) bool { return strings.EqualFold(p.Name, name) })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown device profile %q (valid choices: %s)", name, strings.Join(Profiles(), ", "))
+	}
+	return profiles[i], nil
+}
+
+// orDefault returns p, or ProfileGM1356 if p is nil
+func (p *DeviceProfile) orDefault() *DeviceProfile {
+	if p == nil {
+		return &ProfileGM1356
+	}
+	return p
+}
+
+// Mode decodes fast/slow mode from a config byte
+func (p *DeviceProfile) Mode(b byte) string {
+	if b&p.FastBit != 0 {
+		return "fast"
+	}
+	return "slow"
+}
+
+// FreqMode decodes dBA/dBC mode from a config byte
+func (p *DeviceProfile) FreqMode(b byte) string {
+	if b&p.DBCMask != 0 {
+		return "dBC"
+	}
+	return "dBA"
+}
+
+// Range extracts the measurement range from a config byte
+func (p *DeviceProfile) Range(b byte) string {
+	if rangeStr, exists := p.RangeMap[b&p.RangeMask]; exists {
+		return rangeStr
+	}
+	return "unknown"
+}
+
+// MaxHold decodes the max-hold indicator from a config byte
+func (p *DeviceProfile) MaxHold(b byte) bool {
+	return b&p.MaxHoldBit != 0
+}
+
+// BatteryLow decodes the unconfirmed battery-low indicator from a config byte
+func (p *DeviceProfile) BatteryLow(b byte) bool {
+	return b&p.BatteryLowBit != 0
+}
+
+// Recognized reports whether the range bits of a config byte hold a value in RangeMap; every other field is a single bit
+func (p *DeviceProfile) Recognized(b byte) bool {
+	_, ok := p.RangeMap[b&p.RangeMask]
+	return ok
+}
+
+// LookupRange reverse-looks-up the range bits for a range string such as "50-100"
+func (p *DeviceProfile) LookupRange(value string) (byte, error) {
+	for bits, rangeStr := range p.RangeMap {
+		if rangeStr == value {
+			return bits, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown range %q (valid choices: %s)", value, strings.Join(p.ValidRanges(), ", "))
+}
+
+// ValidRanges lists the range strings from RangeMap in config value order
+func (p *DeviceProfile) ValidRanges() []string {
+	values := make([]int, 0, len(p.RangeMap))
+	for bits := range p.RangeMap {
+		values = append(values, int(bits))
+	}
+	sort.Ints(values)
+
+	ranges := make([]string, 0, len(values))
+	for _, bits := range values {
+		ranges = append(ranges, p.RangeMap[byte(bits)])
+	}
+	return ranges
+}
+
+// Apply merges the requested changes into the given config byte, keeping all other bits as they are
+func (p *DeviceProfile) Apply(s Settings, config byte) (byte, error) {
+	if s.Range != "" {
+		bits, err := p.LookupRange(s.Range)
+		if err != nil {
+			return 0, err
+		}
+		config = config&^p.RangeMask | bits
+	}
+	switch {
+	case s.FreqMode == "":
+	case strings.EqualFold(s.FreqMode, "dBA"):
+		config &^= p.DBCMask // Every bit that decodes as dBC, not just the one set for it
+	case strings.EqualFold(s.FreqMode, "dBC"):
+		config |= p.DBCBit
+	default:
+		return 0, fmt.Errorf("unknown frequency weighting %q (valid choices: dBA, dBC)", s.FreqMode)
+	}
+	if s.Fast != nil {
+		config = setBit(config, p.FastBit, *s.Fast)
+	}
+	if s.MaxHold != nil {
+		config = setBit(config, p.MaxHoldBit, *s.MaxHold)
+	}
+	return config, nil
+}
+
+// Unapplied lists the requested settings a config byte read back from the device doesn't reflect, e.g. "range 50-100 (reads back as 30-130)"
+func (p *DeviceProfile) Unapplied(s Settings, config byte) []string {
+	var missing []string
+	if s.Range != "" && p.Range(config) != s.Range {
+		missing = append(missing, fmt.Sprintf("range %s (reads back as %s)", s.Range, p.Range(config)))
+	}
+	if s.FreqMode != "" && !strings.EqualFold(p.FreqMode(config), s.FreqMode) {
+		missing = append(missing, fmt.Sprintf("weighting %s (reads back as %s)", s.FreqMode, p.FreqMode(config)))
+	}
+	if s.Fast != nil && (p.Mode(config) == "fast") != *s.Fast {
+		want := "slow"
+		if *s.Fast {
+			want = "fast"
+		}
+		missing = append(missing, fmt.Sprintf("%s response (reads back as %s)", want, p.Mode(config)))
+	}
+	if s.MaxHold != nil && p.MaxHold(config) != *s.MaxHold {
+		missing = append(missing, fmt.Sprintf("max hold %t (reads back as %t)", *s.MaxHold, p.MaxHold(config)))
+	}
+	return missing
+}
+
+// Validate checks that the requested settings are supported by the model
+func (p *DeviceProfile) Validate(s Settings) error {
+	_, err := p.Apply(s, 0)
+	return err
+}
+
+// BuildConfigCommand builds the 8-byte config command carrying the given config byte
+func (p *DeviceProfile) BuildConfigCommand(config byte) []byte {
+	return []byte{p.ConfigOpcode, config, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+}
+
+// setBit sets or clears bit in b
+func setBit(b, bit byte, on bool) byte {
+	if on {
+		return b | bit
+	}
+	return b &^ bit
+}
@@ -0,0 +1,137 @@
+package gm1356
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// testProfile is a made-up model with the config fields in other bits, to check that decoding follows the profile
+var testProfile = DeviceProfile{
+	Name:           "test",
+	CaptureCommand: CommandCapture,
+	ConfigOpcode:   0x57,
+	RangeMask:      0x70,
+	DBCBit:         0x01,
+	DBCMask:        0x01,
+	MaxHoldBit:     0x02,
+	FastBit:        0x04,
+	RangeMap:       map[byte]string{0x00: "30-130", 0x10: "40-90"},
+}
+
+func TestLookupProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    *DeviceProfile
+		wantErr bool
+	}{
+		{"gm1356", &ProfileGM1356, false},
+		{"GM1356", &ProfileGM1356, false},
+		{"gm9999", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := LookupProfile(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("LookupProfile(%q) = %v, %v, want %v, wantErr %t", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestProfileDecode(t *testing.T) {
+	now := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name                     string
+		profile                  *DeviceProfile
+		config                   byte
+		mode, freqMode, rangeStr string
+		maxHold, unknown         bool
+	}{
+		{"gm1356 fast dBC", &ProfileGM1356, 0x52, "fast", "dBC", "50-100", false, false},
+		{"gm1356 bits in test layout", &ProfileGM1356, 0x17, "slow", "dBC", "unknown", false, true},
+		{"gm1356 battery low keeps the range", &ProfileGM1356, 0x0A, "slow", "dBA", "50-100", false, false},
+		{"test all clear", &testProfile, 0x00, "slow", "dBA", "30-130", false, false},
+		{"test fast dBC max hold", &testProfile, 0x17, "fast", "dBC", "40-90", true, false},
+		{"test unknown range", &testProfile, 0x20, "slow", "dBA", "unknown", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDecibelData([]byte{0x02, 0x3A, tt.config}, now, VariantStandard, tt.profile)
+			if err != nil {
+				t.Fatalf("parseDecibelData() error = %v", err)
+			}
+			if got.Mode != tt.mode || got.FreqMode != tt.freqMode || got.Range != tt.rangeStr || got.MaxHold != tt.maxHold || got.UnknownConfig != tt.unknown {
+				t.Errorf("parseDecibelData() = %+v, want mode %s, %s, range %s, maxHold %t, unknown %t", got, tt.mode, tt.freqMode, tt.rangeStr, tt.maxHold, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestProfileApply(t *testing.T) {
+	on := true
+	got, err := testProfile.Apply(Settings{Range: "40-90", FreqMode: "dBC", Fast: &on}, 0x80)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if want := byte(0x95); got != want {
+		t.Errorf("Apply() = %#02x, want %#02x", got, want)
+	}
+	if cmd := testProfile.BuildConfigCommand(got); cmd[0] != 0x57 || cmd[1] != got {
+		t.Errorf("BuildConfigCommand(%#02x) = %X", got, cmd)
+	}
+	if err := testProfile.Validate(Settings{Range: "50-100"}); err == nil {
+		t.Error("Validate() accepted a range the profile doesn't have")
+	}
+}
+
+func TestProfileUnapplied(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name     string
+		settings Settings
+		config   byte
+		want     []string
+	}{
+		{"all applied", Settings{Range: "40-90", FreqMode: "dbc", Fast: &on, MaxHold: &off}, 0x15, nil},
+		{"nothing requested", Settings{}, 0x00, nil},
+		{"range ignored", Settings{Range: "40-90"}, 0x00, []string{"range 40-90 (reads back as 30-130)"}},
+		{"weighting and speed ignored", Settings{FreqMode: "dBC", Fast: &off}, 0x04, []string{"weighting dBC (reads back as dBA)", "slow response (reads back as fast)"}},
+		{"max hold ignored", Settings{MaxHold: &on}, 0x00, []string{"max hold true (reads back as false)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testProfile.Unapplied(tt.settings, tt.config)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Unapplied(%#02x) = %q, want %q", tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileApplyReadsBack(t *testing.T) {
+	on := true
+	tests := []struct {
+		name     string
+		settings Settings
+		config   byte
+	}{
+		{"dBA from dBC bit", Settings{FreqMode: "dBA"}, 0x10},
+		{"dBA from other dBC bit", Settings{FreqMode: "dBA"}, 0x80},
+		{"dBA from both dBC bits", Settings{FreqMode: "dBA", Fast: &on}, 0x92},
+		{"dBC", Settings{FreqMode: "dBC"}, 0x00},
+		{"range with battery low", Settings{Range: "60-110"}, 0x0C},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ProfileGM1356.Apply(tt.settings, tt.config)
+			if err != nil {
+				t.Fatalf("Apply(%#02x) error = %v", tt.config, err)
+			}
+			if got := ProfileGM1356.Unapplied(tt.settings, config); got != nil {
+				t.Errorf("Apply(%#02x) = %#02x, which reads back as %q", tt.config, config, got)
+			}
+			if ProfileGM1356.BatteryLow(config) != ProfileGM1356.BatteryLow(tt.config) {
+				t.Errorf("Apply(%#02x) = %#02x, which changes the battery-low bit", tt.config, config)
+			}
+		})
+	}
+}
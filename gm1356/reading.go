@@ -0,0 +1,172 @@
+package gm1356
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// minPacketLen is the number of bytes needed to decode a reading: two level bytes and the config byte
+const minPacketLen = 3
+
+// DecibelReading represents the parsed data from GM1356
+type DecibelReading struct {
+	Time          time.Time `json:"-"` // When the reading was taken; Timestamp is its formatted form
+	Timestamp     string    `json:"timestamp"`
+	Measured      float64   `json:"measured"`    // Calibrated level (RawMeasured plus the calibration offset)
+	RawMeasured   float64   `json:"rawMeasured"` // Level as reported by the device
+	Mode          string    `json:"mode"`
+	FreqMode      string    `json:"freqMode"`
+	Range         string    `json:"range"`
+	MaxHold       bool      `json:"maxHold"`                 // Measured is a held peak rather than the instantaneous level
+	OutOfRange    bool      `json:"outOfRange"`              // Level is outside the selected range, so the value is not reliable
+	BatteryLow    bool      `json:"batteryLow,omitempty"`    // Battery indicator is lit; only decoded with Meter.DecodeBattery
+	Config        byte      `json:"-"`                       // Config byte the mode, weighting, and range were decoded from
+	UnknownConfig bool      `json:"unknownConfig,omitempty"` // Config byte has range bits this package doesn't recognize
+	Extra         string    `json:"extra,omitempty"`         // Hex-encoded bytes after the config byte, set only if any is non-zero (see ParseExtra)
+	Stale         bool      `json:"stale,omitempty"`         // Packet is byte-for-byte the previous one, as when reading faster than the device updates
+	Serial        string    `json:"serial,omitempty"`        // Serial number of the meter that took the reading
+	Raw           string    `json:"raw,omitempty"`           // Hex-encoded packet the reading was decoded from, if requested
+
+	// Seq numbers the readings a caller emits from 1, so a consumer can spot gaps in a lossy stream; filled in by callers
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Leq and Smoothed are derived levels filled in by callers that compute them
+	Leq      float64 `json:"leq,omitempty"`      // Rolling equivalent continuous level
+	Smoothed float64 `json:"smoothed,omitempty"` // Moving energy average of the last few samples
+
+	// Estimate is an approximate level in the other weighting, filled in by callers given an A/C offset
+	Estimate *WeightingEstimate `json:"estimate,omitempty"`
+}
+
+// WeightingEstimate is a level in the weighting the meter isn't set to, derived from the measured one
+type WeightingEstimate struct {
+	FreqMode  string  `json:"freqMode"`
+	Measured  float64 `json:"measured"`
+	Estimated bool    `json:"estimated"` // Always true: the device can't measure both weightings at once
+}
+
+// TimestampLayout is the layout of DecibelReading.Timestamp; the zone is UTC unless a clock in another location is used
+const TimestampLayout = "2006-01-02 15:04:05 MST"
+
+// ParseDecibelData converts raw HID bytes into a structured format, rejecting packets too short to decode
+func ParseDecibelData(buf []byte) (DecibelReading, error) {
+	return parseDecibelData(buf, utcNow(), VariantStandard, &ProfileGM1356)
+}
+
+// ParseDecibelDataVariant is ParseDecibelData for firmware whose level bytes need a different decode variant
+func ParseDecibelDataVariant(buf []byte, variant DecodeVariant) (DecibelReading, error) {
+	return parseDecibelData(buf, utcNow(), variant, &ProfileGM1356)
+}
+
+// Parse is ParseDecibelDataVariant for a meter of this model
+func (p *DeviceProfile) Parse(buf []byte, variant DecodeVariant) (DecibelReading, error) {
+	return parseDecibelData(buf, utcNow(), variant, p)
+}
+
+// Decode decodes a packet taken at the given time the way Meter.Read does, for packets that come from somewhere else:
+// the level and config byte, the battery-low bit if decodeBattery is set, and the calibration offset. A nil p is ProfileGM1356.
+func (p *DeviceProfile) Decode(buf []byte, at time.Time, variant DecodeVariant, decodeBattery bool, calibration float64) (DecibelReading, error) {
+	p = p.orDefault()
+	reading, err := parseDecibelData(buf, at, variant, p)
+	if err != nil {
+		return DecibelReading{}, err
+	}
+	if decodeBattery {
+		reading.BatteryLow = p.BatteryLow(buf[2])
+	}
+	reading.Measured = reading.RawMeasured + calibration
+	return reading, nil
+}
+
+// parseDecibelData decodes buf with the given timestamp so the result is deterministic
+func parseDecibelData(buf []byte, now time.Time, variant DecodeVariant, profile *DeviceProfile) (DecibelReading, error) {
+	if len(buf) < minPacketLen {
+		return DecibelReading{}, fmt.Errorf("%w (got %d bytes, need %d)", ErrShortPacket, len(buf), minPacketLen)
+	}
+
+	// Extract decibel measurement (16-bit)
+	measured := decodeLevel(buf, variant)
+
+	// Determine mode, frequency mode, and range
+	mode := profile.Mode(buf[2])
+	freqMode := profile.FreqMode(buf[2])
+	rangeStr := profile.Range(buf[2])
+	maxHold := profile.MaxHold(buf[2])
+
+	return DecibelReading{
+		Measured:      measured,
+		RawMeasured:   measured,
+		Mode:          mode,
+		FreqMode:      freqMode,
+		Range:         rangeStr,
+		MaxHold:       maxHold,
+		OutOfRange:    ParseOutOfRange(measured, rangeStr),
+		Config:        buf[2],
+		UnknownConfig: !profile.Recognized(buf[2]),
+		Extra:         ParseExtra(buf),
+		Time:          now,
+		Timestamp:     now.Format(TimestampLayout),
+	}, nil
+}
+
+// utcNow is the default clock
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// ParseMode decodes fast/slow mode from the HID buffer
+func ParseMode(b byte) string {
+	return ProfileGM1356.Mode(b)
+}
+
+// ParseFreqMode decodes dBA/dBC mode from the HID buffer
+func ParseFreqMode(b byte) string {
+	return ProfileGM1356.FreqMode(b)
+}
+
+// ParseRange extracts the measurement range from the HID buffer
+func ParseRange(b byte) string {
+	return ProfileGM1356.Range(b)
+}
+
+// RecognizedConfig reports whether every field of a config byte decodes to a known value.
+// Speed, weighting, and max-hold are single bits, so any value decodes; only the range bits can hold a value outside RangeMap,
+// which ParseRange reports as "unknown".
+func RecognizedConfig(b byte) bool {
+	return ProfileGM1356.Recognized(b)
+}
+
+// ParseMaxHold decodes the max-hold indicator from the HID buffer
+func ParseMaxHold(b byte) bool {
+	return ProfileGM1356.MaxHold(b)
+}
+
+// ParseBatteryLow decodes the battery-low indicator from the config byte.
+// This is a best guess rather than a documented flag: the GM1356 protocol notes and the C reference code don't mention the
+// battery, and 0x08 is the only config bit with no known meaning (ranges only use values 0-4 of bits 0-2). Readings from a
+// meter with a weak battery are needed to confirm it, so callers decode it only when asked to.
+func ParseBatteryLow(b byte) bool {
+	return ProfileGM1356.BatteryLow(b)
+}
+
+// ParseExtra returns the bytes after the config byte, hex-encoded, or "" if they are all zero.
+// The GM1356 always sends an 8-byte packet, but neither the protocol notes nor the C reference code use anything past byte 2,
+// and every packet seen so far has zeros there. Some related meters are said to send a secondary value or status in these
+// bytes, so they are passed on rather than dropped until readings from such a meter show what they mean.
+func ParseExtra(buf []byte) string {
+	if len(buf) <= minPacketLen || !slices.ContainsFunc(buf[minPacketLen:], func(b byte) bool { return b != 0 }) {
+		return ""
+	}
+	return hex.EncodeToString(buf[minPacketLen:])
+}
+
+// ParseOutOfRange reports whether a level falls outside the given range, where the meter shows over/under instead of a value.
+// No bit of the config byte has been identified as an over/under-range flag (bits 0-2 are the range, 0x08 possibly battery-low,
+// 0x10 and 0x80 dBC, 0x20 max-hold, 0x40 fast), and the level bytes keep carrying a number, so the condition is inferred from the range bounds.
+// Levels with an unknown range are never reported as out of range.
+func ParseOutOfRange(level float64, rangeStr string) bool {
+	low, high, ok := RangeBounds(rangeStr)
+	return ok && (level < low || level > high)
+}
@@ -0,0 +1,279 @@
+package gm1356
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want string
+	}{
+		{0x00, "slow"},
+		{0x40, "fast"},
+		{0x4F, "fast"},
+		{0xBF, "slow"}, // Every bit except 0x40
+		{0xFF, "fast"},
+	}
+	for _, tt := range tests {
+		if got := ParseMode(tt.b); got != tt.want {
+			t.Errorf("ParseMode(%#02x) = %q, want %q", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseFreqMode(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want string
+	}{
+		{0x00, "dBA"},
+		{0x10, "dBC"},
+		{0x80, "dBC"},
+		{0x90, "dBC"},
+		{0x6F, "dBA"}, // Every bit except 0x10 and 0x80
+	}
+	for _, tt := range tests {
+		if got := ParseFreqMode(tt.b); got != tt.want {
+			t.Errorf("ParseFreqMode(%#02x) = %q, want %q", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want string
+	}{
+		{0x00, "30-130"},
+		{0x01, "30-80"},
+		{0x02, "50-100"},
+		{0x03, "60-110"},
+		{0x04, "80-130"},
+		{0x05, "unknown"},
+		{0x0F, "unknown"},
+		{0x54, "80-130"}, // High nibble flags don't affect the range
+		{0xF2, "50-100"},
+	}
+	for _, tt := range tests {
+		if got := ParseRange(tt.b); got != tt.want {
+			t.Errorf("ParseRange(%#02x) = %q, want %q", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRecognizedConfig(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want bool
+	}{
+		{0x00, true},
+		{0x04, true},
+		{0xF4, true},
+		{0x05, false},
+		{0x0F, false},
+		{0x48, true}, // The battery-low bit is outside the range bits
+		{0x4D, false},
+	}
+	for _, tt := range tests {
+		if got := RecognizedConfig(tt.b); got != tt.want {
+			t.Errorf("RecognizedConfig(%#02x) = %t, want %t", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseMaxHold(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want bool
+	}{
+		{0x00, false},
+		{0x20, true},
+		{0xDF, false},
+		{0xFF, true},
+	}
+	for _, tt := range tests {
+		if got := ParseMaxHold(tt.b); got != tt.want {
+			t.Errorf("ParseMaxHold(%#02x) = %t, want %t", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseBatteryLow(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want bool
+	}{
+		{0x00, false},
+		{0x08, true},
+		{0xF7, false},
+		{0x4A, true},
+	}
+	for _, tt := range tests {
+		if got := ParseBatteryLow(tt.b); got != tt.want {
+			t.Errorf("ParseBatteryLow(%#02x) = %t, want %t", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseExtra(t *testing.T) {
+	tests := []struct {
+		buf  []byte
+		want string
+	}{
+		{[]byte{0x01, 0x3A, 0x00}, ""},
+		{[]byte{0x01, 0x3A, 0x52, 0x00, 0x00, 0x00, 0x00, 0x00}, ""},
+		{[]byte{0x01, 0x3A, 0x52, 0x01, 0x00, 0x00, 0x00, 0x00}, "0100000000"},
+		{[]byte{0x01, 0x3A, 0x52, 0x00, 0xFF}, "00ff"},
+	}
+	for _, tt := range tests {
+		if got := ParseExtra(tt.buf); got != tt.want {
+			t.Errorf("ParseExtra(%v) = %q, want %q", tt.buf, got, tt.want)
+		}
+	}
+}
+
+func TestParseOutOfRange(t *testing.T) {
+	tests := []struct {
+		level    float64
+		rangeStr string
+		want     bool
+	}{
+		{65.3, "30-130", false},
+		{30.0, "30-130", false}, // Bounds are inclusive
+		{130.0, "30-130", false},
+		{29.9, "30-130", true},
+		{130.1, "30-130", true},
+		{85.0, "30-80", true},
+		{45.0, "50-100", true},
+		{120.0, "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := ParseOutOfRange(tt.level, tt.rangeStr); got != tt.want {
+			t.Errorf("ParseOutOfRange(%v, %q) = %t, want %t", tt.level, tt.rangeStr, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecibelData(t *testing.T) {
+	now := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		buf  []byte
+		want DecibelReading
+	}{
+		{
+			name: "slow dBA 30-130",
+			buf:  []byte{0x01, 0x3A, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want: DecibelReading{Measured: 31.4, RawMeasured: 31.4, Mode: "slow", FreqMode: "dBA", Range: "30-130", Config: 0x00},
+		},
+		{
+			name: "fast dBC 50-100",
+			buf:  []byte{0x01, 0xC5, 0x52, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want: DecibelReading{Measured: 45.3, RawMeasured: 45.3, Mode: "fast", FreqMode: "dBC", Range: "50-100", OutOfRange: true, Config: 0x52},
+		},
+		{
+			name: "max hold 80-130",
+			buf:  []byte{0x04, 0xB0, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00},
+			want: DecibelReading{Measured: 120.0, RawMeasured: 120.0, Mode: "slow", FreqMode: "dBA", Range: "80-130", MaxHold: true, Config: 0x24},
+		},
+		{
+			name: "zero level",
+			buf:  []byte{0x00, 0x00, 0x00},
+			want: DecibelReading{Measured: 0, RawMeasured: 0, Mode: "slow", FreqMode: "dBA", Range: "30-130", OutOfRange: true, Config: 0x00},
+		},
+		{
+			name: "trailing bytes",
+			buf:  []byte{0x01, 0x3A, 0x00, 0x00, 0x12, 0x00, 0x00, 0xA0},
+			want: DecibelReading{Measured: 31.4, RawMeasured: 31.4, Mode: "slow", FreqMode: "dBA", Range: "30-130", Config: 0x00, Extra: "00120000a0"},
+		},
+		{
+			name: "16-bit maximum",
+			buf:  []byte{0xFF, 0xFF, 0x0F},
+			want: DecibelReading{Measured: 6553.5, RawMeasured: 6553.5, Mode: "slow", FreqMode: "dBA", Range: "unknown", Config: 0x0F, UnknownConfig: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Time = now
+			tt.want.Timestamp = "2025-03-01 05:04:00 UTC"
+			got, err := parseDecibelData(tt.buf, now, VariantStandard, &ProfileGM1356)
+			if err != nil {
+				t.Fatalf("parseDecibelData() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseDecibelData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileDecodeOptions(t *testing.T) {
+	now := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	packet := []byte{0x01, 0x3A, 0x0A} // 31.4 dB, 50-100 with the battery-low bit set
+	tests := []struct {
+		name          string
+		profile       *DeviceProfile
+		decodeBattery bool
+		calibration   float64
+		wantMeasured  float64
+		wantBattery   bool
+	}{
+		{"defaults", &ProfileGM1356, false, 0, 31.4, false},
+		{"battery decoded", &ProfileGM1356, true, 0, 31.4, true},
+		{"calibrated", &ProfileGM1356, false, -1.5, 29.9, false},
+		{"nil profile is gm1356", nil, true, 0, 31.4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.profile.Decode(packet, now, VariantStandard, tt.decodeBattery, tt.calibration)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if math.Abs(got.Measured-tt.wantMeasured) > 1e-9 || got.RawMeasured != 31.4 || got.BatteryLow != tt.wantBattery || got.Range != "50-100" || !got.Time.Equal(now) {
+				t.Errorf("Decode() = %+v, want measured %v, batteryLow %t, range 50-100", got, tt.wantMeasured, tt.wantBattery)
+			}
+		})
+	}
+	if _, err := ProfileGM1356.Decode(packet[:2], now, VariantStandard, true, 0); !errors.Is(err, ErrShortPacket) {
+		t.Errorf("Decode() of a short packet error = %v, want ErrShortPacket", err)
+	}
+}
+
+func TestParseDecibelDataShortPacket(t *testing.T) {
+	for _, buf := range [][]byte{nil, {0x01}, {0x01, 0x3A}} {
+		if _, err := ParseDecibelData(buf); !errors.Is(err, ErrShortPacket) {
+			t.Errorf("ParseDecibelData(%v) error = %v, want ErrShortPacket", buf, err)
+		}
+	}
+}
+
+// maxParseAllocs is what decoding a packet may allocate: the formatted timestamp; trailing bytes add their hex string on top
+const maxParseAllocs = 1
+
+func TestParseDecibelDataAllocs(t *testing.T) {
+	now := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	packet := []byte{0x01, 0x3A, 0x42, 0x00, 0x00, 0x00, 0x00, 0x00}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := parseDecibelData(packet, now, VariantStandard, &ProfileGM1356); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > maxParseAllocs {
+		t.Errorf("parseDecibelData() allocates %v times per packet, want at most %d", allocs, maxParseAllocs)
+	}
+}
+
+func BenchmarkParseDecibelData(b *testing.B) {
+	now := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	packet := []byte{0x01, 0x3A, 0x42, 0x00, 0x00, 0x00, 0x00, 0x00}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := parseDecibelData(packet, now, VariantStandard, &ProfileGM1356); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package gm1356
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulatorUsesInjectedClock(t *testing.T) {
+	sim, err := NewSimulator(ProfileSteady)
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	berlin := time.FixedZone("CET", 60*60)
+	sim.Now = func() time.Time { return time.Date(2025, 3, 1, 6, 4, 0, 0, berlin) }
+
+	reading, err := sim.Read(t.Context())
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if want := "2025-03-01 06:04:00 CET"; reading.Timestamp != want {
+		t.Errorf("Timestamp = %q, want %q", reading.Timestamp, want)
+	}
+}
+
+func TestSimulatorProfiles(t *testing.T) {
+	for _, profile := range SimulationProfiles() {
+		sim, err := NewSimulator(profile)
+		if err != nil {
+			t.Fatalf("NewSimulator(%q) error = %v", profile, err)
+		}
+		for i := 0; i < 100; i++ {
+			reading, err := sim.Read(t.Context())
+			if err != nil {
+				t.Fatalf("%s: Read() error = %v", profile, err)
+			}
+			if reading.Measured < 40 || reading.Measured > 90 {
+				t.Errorf("%s: Measured = %.1f, want within 40-90 dB", profile, reading.Measured)
+			}
+		}
+	}
+	if _, err := NewSimulator("bogus"); err == nil {
+		t.Error("NewSimulator(\"bogus\") succeeded, want error")
+	}
+}
+
+func TestSimulatorFlagsStale(t *testing.T) {
+	sim, err := NewSimulator(ProfileSteady)
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	var previous DecibelReading
+	for i := 0; i < 100; i++ {
+		reading, err := sim.Read(t.Context())
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if want := i > 0 && reading.RawMeasured == previous.RawMeasured; reading.Stale != want {
+			t.Errorf("read %d: Stale = %t for %.1f dB after %.1f dB, want %t", i, reading.Stale, reading.RawMeasured, previous.RawMeasured, want)
+		}
+		previous = reading
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"usb-decibel-meter/decibelpb"
+	"usb-decibel-meter/gm1356"
+)
+
+// readingService implements the DecibelMeter gRPC service on top of the reading feed
+type readingService struct {
+	decibelpb.UnimplementedDecibelMeterServer
+	feed *broadcaster[*decibelpb.Reading]
+}
+
+// StreamReadings sends every broadcast reading until the client goes away or the feed is closed
+func (s *readingService) StreamReadings(_ *emptypb.Empty, stream decibelpb.DecibelMeter_StreamReadingsServer) error {
+	readings := s.feed.subscribe()
+	defer s.feed.unsubscribe(readings)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case reading, ok := <-readings:
+			if !ok {
+				// Dropped for falling behind, or shutting down
+				return nil
+			}
+			if err := stream.Send(reading); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toProtoReading converts a reading to its gRPC message
+func toProtoReading(data gm1356.DecibelReading) *decibelpb.Reading {
+	return &decibelpb.Reading{
+		Time:          timestamppb.New(data.Time),
+		Timestamp:     data.Timestamp,
+		Measured:      data.Measured,
+		RawMeasured:   data.RawMeasured,
+		Mode:          data.Mode,
+		FreqMode:      data.FreqMode,
+		Range:         data.Range,
+		MaxHold:       data.MaxHold,
+		OutOfRange:    data.OutOfRange,
+		Serial:        data.Serial,
+		Raw:           data.Raw,
+		Leq:           data.Leq,
+		Smoothed:      data.Smoothed,
+		Seq:           data.Seq,
+		BatteryLow:    data.BatteryLow,
+		UnknownConfig: data.UnknownConfig,
+		Stale:         data.Stale,
+	}
+}
+
+// grpcServer is the running gRPC server with its health service
+type grpcServer struct {
+	server *grpc.Server
+	health *health.Server
+}
+
+// startGRPCServer serves the reading stream and the standard health service at addr; it returns once the listener is bound
+func startGRPCServer(addr string, feed *broadcaster[*decibelpb.Reading]) (*grpcServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &grpcServer{server: grpc.NewServer(), health: health.NewServer()}
+	decibelpb.RegisterDecibelMeterServer(s.server, &readingService{feed: feed})
+	healthpb.RegisterHealthServer(s.server, s.health)
+	s.health.SetServingStatus(decibelpb.DecibelMeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// shutdown reports NOT_SERVING to health checks and stops the server, cutting off streams that don't end within 2 seconds
+func (s *grpcServer) shutdown() {
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		s.server.Stop()
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// heartbeat signals liveness to an external watchdog, by touching --heartbeat-file on every reading and logging a line
+// every --heartbeat-interval; a nil *heartbeat is a no-op
+type heartbeat struct {
+	path string // Empty if only the log line is wanted
+
+	mu       sync.Mutex
+	readings int       // Readings since the last heartbeat line
+	last     time.Time // When the last reading was emitted
+	failed   bool      // Touching the file failed, so the error isn't logged again for every reading
+}
+
+// beat records a successful reading and updates the mtime of the heartbeat file, creating it if needed
+func (h *heartbeat) beat() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.readings++
+	h.last = now
+	if h.path == "" {
+		return
+	}
+	err := os.Chtimes(h.path, now, now)
+	if os.IsNotExist(err) {
+		var file *os.File
+		if file, err = os.Create(h.path); err == nil {
+			err = file.Close()
+		}
+	}
+	if err != nil && !h.failed {
+		slog.Error("Failed to touch heartbeat file", "path", h.path, "err", err)
+	}
+	h.failed = err != nil
+}
+
+// log writes a heartbeat line every interval until ctx is cancelled
+func (h *heartbeat) log(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.mu.Lock()
+		readings, last := h.readings, h.last
+		h.readings = 0
+		h.mu.Unlock()
+
+		if readings == 0 {
+			args := []any{"for", interval}
+			if !last.IsZero() {
+				args = append(args, "lastReading", last.Format(time.RFC3339))
+			}
+			slog.Warn("Heartbeat: no readings", args...)
+			continue
+		}
+		slog.Info("Heartbeat", "readings", readings, "in", interval)
+	}
+}
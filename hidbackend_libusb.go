@@ -0,0 +1,6 @@
+//go:build linux && libusb
+
+package main
+
+// hidBackend is the HIDAPI backend compiled in; the libusb one talks to /dev/bus/usb instead of /dev/hidraw*
+const hidBackend = "libusb"
@@ -0,0 +1,6 @@
+//go:build !(linux && libusb)
+
+package main
+
+// hidBackend is the HIDAPI backend compiled in: hidraw on Linux, the platform's HID API elsewhere
+const hidBackend = "native"
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// haUnsafe matches the characters Home Assistant doesn't allow in discovery object IDs
+var haUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// haDiscovery announces each meter as a Home Assistant sensor over MQTT and publishes its readings to the sensor's state topic;
+// a nil *haDiscovery is a no-op
+type haDiscovery struct {
+	publisher *mqttPublisher
+	prefix    string // Discovery prefix Home Assistant subscribes to, "homeassistant" by default
+}
+
+// haConfig is the MQTT discovery payload of a sensor entity
+type haConfig struct {
+	Name               string   `json:"name"`
+	UniqueID           string   `json:"unique_id"`
+	ObjectID           string   `json:"object_id"`
+	DeviceClass        string   `json:"device_class"`
+	StateClass         string   `json:"state_class"`
+	Unit               string   `json:"unit_of_measurement"`
+	StateTopic         string   `json:"state_topic"`
+	ValueTemplate      string   `json:"value_template"`
+	AttributesTopic    string   `json:"json_attributes_topic"`
+	AttributesTemplate string   `json:"json_attributes_template"`
+	Device             haDevice `json:"device"`
+}
+
+// haDevice groups the sensor under a device in the Home Assistant UI
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SerialNumber string   `json:"serial_number,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// haObjectID derives a stable entity ID from the meter's serial number
+func haObjectID(serial string) string {
+	if serial == "" {
+		return "gm1356"
+	}
+	return "gm1356_" + haUnsafe.ReplaceAllString(serial, "_")
+}
+
+// stateTopic is where the readings of the meter with the given serial are published
+func (h *haDiscovery) stateTopic(serial string) string {
+	return h.prefix + "/sensor/" + haObjectID(serial) + "/state"
+}
+
+// announce publishes the retained discovery config for a meter, so Home Assistant creates the sensor even after it restarts
+func (h *haDiscovery) announce(info gm1356.DeviceInfo) error {
+	if h == nil {
+		return nil
+	}
+	id := haObjectID(info.Serial)
+	name := "GM1356 Sound Level Meter"
+	if info.Serial != "" {
+		name += " " + info.Serial
+	}
+	config := haConfig{
+		Name:               "Sound level",
+		UniqueID:           id + "_sound_level",
+		ObjectID:           id + "_sound_level",
+		DeviceClass:        "sound_pressure",
+		StateClass:         "measurement",
+		Unit:               "dB",
+		StateTopic:         h.stateTopic(info.Serial),
+		ValueTemplate:      "{{ value_json.measured }}",
+		AttributesTopic:    h.stateTopic(info.Serial),
+		AttributesTemplate: `{{ {"mode": value_json.mode, "weighting": value_json.freqMode, "range": value_json.range, "max_hold": value_json.maxHold} | tojson }}`,
+		Device: haDevice{
+			Identifiers:  []string{id},
+			Name:         name,
+			Manufacturer: info.Manufacturer,
+			Model:        info.Product,
+			SerialNumber: info.Serial,
+			SWVersion:    info.Release,
+		},
+	}
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	h.publisher.publishTo(h.prefix+"/sensor/"+id+"/config", payload, true)
+	return nil
+}
+
+// publishState sends a JSON-encoded reading to the state topic of the meter that took it
+func (h *haDiscovery) publishState(data gm1356.DecibelReading, payload []byte) {
+	if h == nil {
+		return
+	}
+	h.publisher.publishTo(h.stateTopic(data.Serial), payload, false)
+}
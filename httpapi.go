@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// latestReading holds the most recent reading so HTTP requests never touch the device loop; a nil *latestReading is a no-op
+type latestReading struct {
+	mu           sync.RWMutex
+	data         gm1356.DecibelReading
+	valid        bool
+	disconnected bool
+}
+
+// set stores a new reading and marks the device as connected
+func (l *latestReading) set(data gm1356.DecibelReading) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.data = data
+	l.valid = true
+	l.disconnected = false
+}
+
+// markDisconnected flags the device as unavailable until the next successful reading
+func (l *latestReading) markDisconnected() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.disconnected = true
+}
+
+// get returns the latest reading and an explanation if it can't be served
+func (l *latestReading) get() (gm1356.DecibelReading, string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	switch {
+	case !l.valid:
+		return gm1356.DecibelReading{}, "no reading available yet"
+	case l.disconnected:
+		return gm1356.DecibelReading{}, "device disconnected"
+	}
+	return l.data, ""
+}
+
+// readingHandler serves GET /reading with the latest reading, or 503 if there is none
+func readingHandler(latest *latestReading) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		data, unavailable := latest.get()
+		if unavailable != "" {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": unavailable})
+			return
+		}
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+// peakHandler serves GET /peak with the held Lmax and Lmin, or 503 if nothing was read since the last reset
+func peakHandler(peaks *peakHold) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		levels := peaks.get()
+		if levels.Samples == 0 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "no reading since the last reset"})
+			return
+		}
+		writeJSON(w, http.StatusOK, levels)
+	}
+}
+
+// resetHandler serves POST /reset, clearing the held Lmax and Lmin
+func resetHandler(peaks *peakHold) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		peaks.reset()
+		writeJSON(w, http.StatusOK, peaks.get())
+	}
+}
+
+// writeJSON writes v as a JSON response body with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// startHTTPServer serves the REST API at addr; it returns once the listener is bound so address errors surface at startup
+func startHTTPServer(addr string, latest *latestReading, peaks *peakHold) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/reading", readingHandler(latest))
+	mux.Handle("/peak", peakHandler(peaks))
+	mux.Handle("/reset", resetHandler(peaks))
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
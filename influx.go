@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// influxMaxBuffered is the most points kept queued; the oldest are dropped beyond this while the server is unreachable
+const influxMaxBuffered = 10000
+
+// influxBatch is the default batching: flush every interval, or early once rows points are queued
+var influxBatch = flushPolicy{interval: 5 * time.Second, rows: 500}
+
+// influxTagEscaper escapes the characters line protocol treats specially in tag keys and values
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// influxWriter batches readings as line-protocol points for the InfluxDB v2 write API; a nil *influxWriter is a no-op
+type influxWriter struct {
+	client   *http.Client
+	writeURL string
+	token    string
+	tags     string // Extra metadata tags, pre-escaped, e.g. ",location=kitchen"
+	batch    flushPolicy
+
+	mu     sync.Mutex
+	points []string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newInfluxWriter builds the write endpoint for the given org and bucket and starts the periodic flush; limits set in batch replace the default batching
+func newInfluxWriter(baseURL, org, bucket, token string, tags [][2]string, batch flushPolicy) (*influxWriter, error) {
+	endpoint, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	endpoint = endpoint.JoinPath("api", "v2", "write")
+	endpoint.RawQuery = url.Values{"org": {org}, "bucket": {bucket}, "precision": {"ns"}}.Encode()
+
+	var extra strings.Builder
+	for _, tag := range tags {
+		extra.WriteString("," + influxTagEscaper.Replace(tag[0]) + "=" + influxTagEscaper.Replace(tag[1]))
+	}
+
+	w := &influxWriter{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		writeURL: endpoint.String(),
+		token:    token,
+		tags:     extra.String(),
+		batch:    batch.withDefaults(influxBatch),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.flushLoop()
+	return w, nil
+}
+
+// formatInfluxPoint renders a reading as a line-protocol point tagged with its mode, weighting, and range, its serial when several meters are read, plus any pre-escaped extra tags
+func formatInfluxPoint(data gm1356.DecibelReading, at time.Time, tags string) string {
+	if multiDevice {
+		tags = ",serial=" + influxTagEscaper.Replace(data.Serial) + tags
+	}
+	return fmt.Sprintf("decibel,mode=%s,freq=%s,range=%s%s value=%s %d",
+		influxTagEscaper.Replace(data.Mode),
+		influxTagEscaper.Replace(data.FreqMode),
+		influxTagEscaper.Replace(data.Range),
+		tags,
+		strconv.FormatFloat(data.Measured, 'f', -1, 64),
+		at.UnixNano())
+}
+
+// write queues a reading, flushing right away once a full batch is waiting
+func (w *influxWriter) write(data gm1356.DecibelReading, at time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.points = append(w.points, formatInfluxPoint(data, at, w.tags))
+	if len(w.points) > influxMaxBuffered {
+		w.points = w.points[len(w.points)-influxMaxBuffered:]
+	}
+	full := len(w.points) >= w.batch.rows
+	w.mu.Unlock()
+
+	if full {
+		go w.flush()
+	}
+}
+
+// flushLoop flushes queued points every batch interval until close is called
+func (w *influxWriter) flushLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.batch.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+// flush sends every queued point in one request; on failure the points are kept for the next attempt
+func (w *influxWriter) flush() error {
+	w.mu.Lock()
+	batch := w.points
+	w.points = nil
+	w.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	err := w.send(batch)
+	if err != nil {
+		slog.Warn("Failed to write points to InfluxDB, will retry", "points", len(batch), "err", err)
+		w.mu.Lock()
+		w.points = append(batch, w.points...)
+		if len(w.points) > influxMaxBuffered {
+			w.points = w.points[len(w.points)-influxMaxBuffered:]
+		}
+		w.mu.Unlock()
+	}
+	return err
+}
+
+// send posts a batch of points to the write API
+func (w *influxWriter) send(batch []string) error {
+	body := strings.Join(batch, "\n")
+	req, err := http.NewRequest(http.MethodPost, w.writeURL, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// close stops the periodic flush and writes out any remaining points
+func (w *influxWriter) close() {
+	if w == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	if err := w.flush(); err != nil {
+		slog.Error("Dropping unsent InfluxDB points on exit", "err", err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+func TestFormatInfluxPoint(t *testing.T) {
+	defer func(saved bool) { multiDevice = saved }(multiDevice)
+	at := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	data := gm1356.DecibelReading{Measured: 42.3, Mode: "slow", FreqMode: "dBA", Range: "30-130", Serial: "rack 1"}
+
+	tests := []struct {
+		name        string
+		multiDevice bool
+		tags        string
+		want        string
+	}{
+		{"one meter", false, ",location=kitchen", "decibel,mode=slow,freq=dBA,range=30-130,location=kitchen value=42.3 1740805440000000000"},
+		{"several meters", true, ",location=kitchen", `decibel,mode=slow,freq=dBA,range=30-130,serial=rack\ 1,location=kitchen value=42.3 1740805440000000000`},
+	}
+	for _, tt := range tests {
+		multiDevice = tt.multiDevice
+		if got := formatInfluxPoint(data, at, tt.tags); got != tt.want {
+			t.Errorf("%s: formatInfluxPoint() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
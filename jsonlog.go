@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// jsonLog writes one JSON record per line to a file, or stdout for "-", rotating the file like the CSV log; a nil *jsonLog is a no-op
+type jsonLog struct {
+	filename string // "-" for stdout, which is never rotated
+	flush    flushPolicy
+	maxSize  int64         // 0 disables size-based rotation
+	maxAge   time.Duration // 0 disables age-based rotation
+
+	file      *os.File
+	written   *countingWriter // Bytes in the file, counting everything flushed to it; nil for stdout
+	writer    *bufio.Writer
+	opened    time.Time
+	pending   int // Lines written since the last flush
+	lastFlush time.Time
+}
+
+// setupJSONLog opens the --output file for appending, or stdout for "-"
+func setupJSONLog(filename string, flush flushPolicy, maxSize int64, maxAge time.Duration) (*jsonLog, error) {
+	l := &jsonLog{filename: filename, flush: flush, maxSize: maxSize, maxAge: maxAge, lastFlush: time.Now()}
+	if filename == "-" {
+		l.file = os.Stdout
+		l.writer = bufio.NewWriter(os.Stdout)
+		l.maxSize, l.maxAge = 0, 0
+		return l, nil
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open opens the active file for appending
+func (l *jsonLog) open() error {
+	file, err := os.OpenFile(l.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	l.file = file
+	l.written = &countingWriter{w: file, n: size}
+	l.writer = bufio.NewWriter(l.written)
+	l.opened = time.Now()
+	return nil
+}
+
+// write appends a JSON record as one line, rotating the file first if it has reached its size or age limit
+func (l *jsonLog) write(record []byte) error {
+	if l == nil {
+		return nil
+	}
+	if l.needsRotation() {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	l.writer.Write(record)
+	if err := l.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	l.pending++
+	if l.flush.due(l.pending, l.lastFlush) {
+		return l.Flush()
+	}
+	return nil
+}
+
+// toStdout reports whether records go to stdout, which then replaces the regular per-reading output
+func (l *jsonLog) toStdout() bool {
+	return l != nil && l.file == os.Stdout
+}
+
+// Flush writes any buffered lines out
+func (l *jsonLog) Flush() error {
+	if l == nil {
+		return nil
+	}
+	l.pending = 0
+	l.lastFlush = time.Now()
+	return l.writer.Flush()
+}
+
+// Close flushes buffered lines and closes the active file, leaving stdout open
+func (l *jsonLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	err := l.writer.Flush()
+	if l.file == os.Stdout {
+		return err
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// needsRotation reports whether the active file has reached a configured limit, counting buffered lines without flushing them
+func (l *jsonLog) needsRotation() bool {
+	if l.maxAge > 0 && time.Since(l.opened) >= l.maxAge {
+		return true
+	}
+	return l.maxSize > 0 && l.written.n+int64(l.writer.Buffered()) >= l.maxSize
+}
+
+// rotate renames the active file with a timestamp suffix and starts a fresh one
+func (l *jsonLog) rotate() error {
+	if err := l.Close(); err != nil {
+		return err
+	}
+
+	rotated := rotatedName(l.filename, time.Now())
+	if err := os.Rename(l.filename, rotated); err != nil {
+		// Keep writing to the existing file rather than losing records
+		slog.Error("Failed to rotate output file", "file", l.filename, "err", err)
+		return l.open()
+	}
+	slog.Info("Rotated output file", "file", rotated)
+	return l.open()
+}
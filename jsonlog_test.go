@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testRecord is a JSON record, testRecordSize bytes with its newline
+var testRecord = []byte(`{"timestamp":"2025-03-01 05:04:00 UTC","measured":42.0}`)
+
+const testRecordSize = int64(len(`{"timestamp":"2025-03-01 05:04:00 UTC","measured":42.0}`) + 1)
+
+func TestJSONLogRotation(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  int64 // Bytes already in the file when it is opened
+		maxSize   int64
+		flush     flushPolicy
+		records   int
+		wantFiles int
+	}{
+		{"no limit", 0, 0, flushPolicy{}, 10, 1},
+		{"size not reached", 0, 1000, flushPolicy{}, 10, 1},
+		{"size reached", 0, 150, flushPolicy{}, 5, 2},
+		{"size counts buffered lines", 0, 150, flushPolicy{rows: 1000}, 5, 2},
+		{"size counts the existing file", 100, 150, flushPolicy{}, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "readings.ndjson")
+			if tt.existing > 0 {
+				if err := os.WriteFile(filename, make([]byte, tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l, err := setupJSONLog(filename, tt.flush, tt.maxSize, 0)
+			if err != nil {
+				t.Fatalf("setupJSONLog() error = %v", err)
+			}
+			for range tt.records {
+				if err := l.write(testRecord); err != nil {
+					t.Fatalf("write() error = %v", err)
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.wantFiles {
+				t.Errorf("%d records left %d files, want %d", tt.records, len(entries), tt.wantFiles)
+			}
+			for _, entry := range entries {
+				info, _ := entry.Info()
+				if tt.maxSize > 0 && info.Size() > tt.maxSize+testRecordSize {
+					t.Errorf("%s is %d bytes, more than a record past the %d byte limit", entry.Name(), info.Size(), tt.maxSize)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// leqTracker computes the equivalent continuous sound level (Leq) by averaging sample energy rather than decibels, kept separately per frequency weighting since averaging dBA with dBC is meaningless
+type leqTracker struct {
+	mu       sync.Mutex
+	segments map[string]*leqSegment // Keyed by frequency weighting
+	order    []string               // Weightings in the order they were first seen
+
+	// window, if non-zero, enables a rolling Leq over the most recent samples
+	window time.Duration
+}
+
+// leqSegment accumulates the samples taken with one frequency weighting
+type leqSegment struct {
+	energySum float64
+	count     int
+	samples   []leqSample
+	rolling   float64 // energy sum of samples
+}
+
+// leqSample is a sample held in the rolling window
+type leqSample struct {
+	at     time.Time
+	energy float64
+}
+
+// add records a measured level and returns the rolling Leq over the window for that weighting (0 if no window is configured)
+func (t *leqTracker) add(at time.Time, level float64, freqMode string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seg := t.segments[freqMode]
+	if seg == nil {
+		if t.segments == nil {
+			t.segments = map[string]*leqSegment{}
+		}
+		seg = &leqSegment{}
+		t.segments[freqMode] = seg
+		t.order = append(t.order, freqMode)
+	}
+
+	energy := gm1356.Energy(level)
+	seg.energySum += energy
+	seg.count++
+
+	if t.window == 0 {
+		return 0
+	}
+	seg.samples = append(seg.samples, leqSample{at: at, energy: energy})
+	seg.rolling += energy
+	return t.rollingLevel(seg, at)
+}
+
+// current returns the rolling Leq for a weighting as of at without recording a sample, for readings kept out of it (0 if no window is configured or no samples are in it)
+func (t *leqTracker) current(at time.Time, freqMode string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seg := t.segments[freqMode]
+	if t.window == 0 || seg == nil {
+		return 0
+	}
+	return t.rollingLevel(seg, at)
+}
+
+// rollingLevel drops the samples that have fallen out of the window ending at at and returns the Leq of the rest; the caller must hold t.mu
+func (t *leqTracker) rollingLevel(seg *leqSegment, at time.Time) float64 {
+	cutoff := at.Add(-t.window)
+	drop := 0
+	for drop < len(seg.samples) && !seg.samples[drop].at.After(cutoff) {
+		seg.rolling -= seg.samples[drop].energy
+		drop++
+	}
+	seg.samples = seg.samples[drop:]
+	if len(seg.samples) == 0 {
+		seg.rolling = 0 // Shed any rounding error left from the dropped samples
+		return 0
+	}
+	return gm1356.Level(seg.rolling / float64(len(seg.samples)))
+}
+
+// leqResult is the session Leq for one frequency weighting
+type leqResult struct {
+	freqMode string
+	level    float64
+	count    int
+}
+
+// session returns the Leq over every sample recorded so far, one result per frequency weighting seen
+func (t *leqTracker) session() []leqResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var results []leqResult
+	for _, freqMode := range t.order {
+		seg := t.segments[freqMode]
+		results = append(results, leqResult{freqMode: freqMode, level: gm1356.Level(seg.energySum / float64(seg.count)), count: seg.count})
+	}
+	return results
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// leqTestSample is a sample fed to a leqTracker, offset from a fixed start time
+type leqTestSample struct {
+	offset   time.Duration
+	level    float64
+	freqMode string
+}
+
+func TestLeqTrackerRolling(t *testing.T) {
+	start := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		window  time.Duration
+		samples []leqTestSample
+		want    float64 // Rolling Leq returned for the last sample
+	}{
+		{"no window", 0, []leqTestSample{{0, 60, "dBA"}}, 0},
+		{"single sample", time.Minute, []leqTestSample{{0, 60, "dBA"}}, 60},
+		{"energy average", time.Minute, []leqTestSample{{0, 60, "dBA"}, {time.Second, 80, "dBA"}}, 77.0},
+		{"old samples drop out", time.Minute, []leqTestSample{{0, 90, "dBA"}, {30 * time.Second, 60, "dBA"}, {90 * time.Second, 60, "dBA"}}, 60},
+		{"sample on the window edge drops out", time.Minute, []leqTestSample{{0, 90, "dBA"}, {time.Minute, 60, "dBA"}}, 60},
+		{"weightings kept apart", time.Minute, []leqTestSample{{0, 90, "dBC"}, {time.Second, 60, "dBA"}}, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &leqTracker{window: tt.window}
+			var got float64
+			for _, s := range tt.samples {
+				got = tracker.add(start.Add(s.offset), s.level, s.freqMode)
+			}
+			if math.Abs(got-tt.want) > 0.05 {
+				t.Errorf("add() = %.2f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeqTrackerCurrent(t *testing.T) {
+	start := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	tracker := &leqTracker{window: time.Minute}
+	tracker.add(start, 60, "dBA")
+	tracker.add(start.Add(time.Second), 80, "dBA")
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		freqMode string
+		want     float64
+	}{
+		{"within the window", start.Add(2 * time.Second), "dBA", 77.0},
+		{"other weighting", start.Add(2 * time.Second), "dBC", 0},
+		{"first sample expired", start.Add(time.Minute + 500*time.Millisecond), "dBA", 80},
+		{"window empty", start.Add(2 * time.Minute), "dBA", 0},
+	}
+	for _, tt := range tests {
+		if got := tracker.current(tt.at, tt.freqMode); math.Abs(got-tt.want) > 0.05 {
+			t.Errorf("%s: current() = %.2f, want %.1f", tt.name, got, tt.want)
+		}
+	}
+
+	// Peeking doesn't record a sample, so the session Leq still covers only the two added
+	session := tracker.session()
+	if len(session) != 1 || session[0].count != 2 || math.Abs(session[0].level-77.0) > 0.05 {
+		t.Errorf("session() = %+v, want one dBA result of 77.0 dB over 2 samples", session)
+	}
+}
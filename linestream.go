@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// lineWriteTimeout bounds how long a single record may take to reach a stream client
+const lineWriteTimeout = 5 * time.Second
+
+// lineServer streams every broadcast message to each connected client as one line, NDJSON for the JSON feed
+type lineServer struct {
+	listener net.Listener
+	feed     *broadcaster[[]byte]
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// startLineServer accepts clients on listener until close is called
+func startLineServer(listener net.Listener, feed *broadcaster[[]byte]) *lineServer {
+	s := &lineServer{listener: listener, feed: feed, conns: map[net.Conn]struct{}{}}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// serve accepts clients until the listener is closed
+func (s *lineServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle streams records to one client until it disconnects, falls too far behind, or the server shuts down
+func (s *lineServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	messages := s.feed.subscribe()
+	defer s.feed.unsubscribe(messages)
+
+	// Discard anything the client sends; EOF or an error means it went away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		io.Copy(io.Discard, conn)
+	}()
+
+	writer := bufio.NewWriter(conn)
+	for {
+		select {
+		case <-gone:
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return // Dropped for falling behind, or shutting down
+			}
+			conn.SetWriteDeadline(time.Now().Add(lineWriteTimeout))
+			writer.Write(msg)
+			writer.WriteByte('\n')
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close stops accepting clients, disconnects the connected ones, and, for a Unix socket, removes the socket file
+func (s *lineServer) close() {
+	if s == nil {
+		return
+	}
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// listenUnix listens on a Unix socket at path, replacing a socket file left behind by a crash but refusing one that is still in use
+func listenUnix(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s is in use by another process", path)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		slog.Info("Removing stale socket file", "path", path)
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
@@ -1,240 +1,1707 @@
 package main
 
 import (
-	"encoding/csv"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
-	hid "github.com/sstallion/go-hid"
-)
+	"github.com/prometheus/client_golang/prometheus"
 
-// Device Info for GM1356
-const (
-	vendorID  = 25789 // 0x64bd
-	productID = 29923 // 0x74e3
+	"usb-decibel-meter/decibelpb"
+	"usb-decibel-meter/gm1356"
 )
 
-// GM1356 Commands (must be 8 bytes)
 var (
-	commandCapture = []byte{0xB3, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00} // Capture measurement
+	logFileName       string
+	setRange          string
+	weighting         string
+	fastMode          bool
+	slowMode          bool
+	reconnect         bool
+	maxRetries        int
+	format            string
+	quiet             bool
+	verbose           bool
+	interval          time.Duration
+	commandDelay      time.Duration
+	pollDelay         time.Duration
+	noOpCommand       bool
+	recapture         int
+	promAddr          string
+	mqttBroker        string
+	mqttTopic         string
+	mqttUsername      string
+	mqttPassword      string
+	mqttQoS           uint
+	summary           bool
+	leq               bool
+	leqWindow         time.Duration
+	calibration       float64
+	acOffset          float64
+	serial            string
+	threshold         float64
+	thresholdFor      time.Duration
+	onAlert           string
+	sqlitePath        string
+	duration          time.Duration
+	sampleCount       int
+	setMaxHold        bool
+	wsAddr            string
+	unixSocket        string
+	tcpAddr           string
+	httpAddr          string
+	logLevel          string
+	influxURL         string
+	influxBucket      string
+	influxToken       string
+	influxOrg         string
+	statsdAddr        string
+	statsdDog         bool
+	logMaxSize        int64
+	outputFile        string
+	logMaxAge         time.Duration
+	resume            bool
+	resumeGap         time.Duration
+	resumeMarker      bool
+	simulate          bool
+	simProfile        string
+	localTime         bool
+	timezone          string
+	tsFormat          string
+	roundTo           time.Duration
+	smoothWindow      int
+	decimate          int
+	deviceHeader      bool
+	percentiles       string
+	waitForDevice     bool
+	includeRaw        bool
+	fieldList         string
+	selfTest          bool
+	dedup             bool
+	excludeOutOfRange bool
+	tuiMode           bool
+	tuiThreshold      float64
+	configFile        string
+	profileName       string
+	vendorID          = hexID(gm1356.VendorID)
+	productID         = hexID(gm1356.ProductID)
+	listDevices       bool
+	aggregate         time.Duration
+	otelEndpoint      string
+	location          string
+	hostname          string
+	appendMeta        bool
+	tags              = tagFlag{}
+	decodeVariant     string
+	readTimeout       time.Duration
+	grpcAddr          string
+	once              bool
+	allDevices        bool
+	pidPath           string
+	precision         int
+	jsonPretty        bool
+	warmup            int
+	retryBase         time.Duration
+	retryMax          time.Duration
+	heartbeatFile     string
+	heartbeatLog      time.Duration
+	minValid          float64
+	maxValid          float64
+	useSyslog         bool
+	syslogAddr        string
+	syslogFacil       string
+	syslogSev         string
+	csvDelimiter      string
+	csvNoHeader       bool
+	csvCRLF           bool
+	csvEpoch          bool
+	decodeBattery     bool
+	replayFile        string
+	replayRealtm      bool
+	strict            bool
+	haDiscover        bool
+	haPrefix          string
+	flushEvery        time.Duration
+	flushRows         int
+	dashboardAddr     string
+	burstFor          time.Duration
+	burstTrigger      float64
+	stdinRaw          bool
 )
 
-// DecibelReading represents the parsed data from GM1356
-type DecibelReading struct {
-	Timestamp string  `json:"timestamp"`
-	Measured  float64 `json:"measured"`
-	Mode      string  `json:"mode"`
-	FreqMode  string  `json:"freqMode"`
-	Range     string  `json:"range"`
-}
+// meta is attached to every emitted record; it is empty unless --location, --tag, or --append-metadata is given
+var meta metadata
+
+// fields is the --fields selection of reading fields for JSON and CSV records; nil means all of them
+var fields []string
 
-// Range mapping based on the C code definition
-var rangeMap = map[byte]string{
-	0x0: "30-130",
-	0x1: "30-80",
-	0x2: "50-100",
-	0x3: "60-110",
-	0x4: "80-130",
+// profile is the --profile model the meters are driven with
+var profile *gm1356.DeviceProfile
+
+// multiDevice is set when several meters are read at once; records then carry a serial column and per-device labels
+var multiDevice bool
+
+// source produces readings for the read loop; it is either the real meter or a simulator
+type source interface {
+	Read(ctx context.Context) (gm1356.DecibelReading, error)
+	ReadConfig(ctx context.Context) (byte, error)
+	SetConfig(ctx context.Context, settings gm1356.Settings) (byte, error)
+	Info() gm1356.DeviceInfo
+	Reopen() error
+	Close() error
 }
 
-var logFileName string
+// statusOut receives the session summary; it is moved to stderr when stdout carries a machine-readable stream
+var statusOut io.Writer = os.Stdout
+
+// Output formats
+const (
+	formatJSON      = "json"
+	formatNDJSON    = "ndjson"
+	formatValue     = "value"           // Just the measured level, one per line
+	formatValueUnit = "value-with-unit" // Measured level followed by dBA or dBC
+)
+
+// shutdownTimeout is how long the reader gets to stop and flush its outputs once shutdown starts
+const shutdownTimeout = 5 * time.Second
+
+// Subcommands; read is the default when none is given
+const (
+	commandRead   = "read"
+	commandConfig = "config"
+	commandInfo   = "info"
+)
+
+// Exit statuses, so scripts can tell user mistakes from device trouble
+const (
+	exitFailure        = 1   // Any other failure, e.g. an output that can't be set up
+	exitUsage          = 2   // Invalid flags or config file, the same status the flag package uses
+	exitDeviceNotFound = 3   // No matching meter could be found or opened
+	exitReadFailure    = 4   // The meter was opened but reading or configuring it failed
+	exitAlert          = 5   // A --threshold alert fired during the session
+	forceQuitExitCode  = 130 // The conventional status for a process ended by SIGINT
+)
+
+// onceAttempts is how many reads --once tries before giving up
+const onceAttempts = 3
+
+// reconnectAfterErrors is the number of consecutive read errors that triggers a reconnect
+const reconnectAfterErrors = 3
+
+// Backoff limits between reconnect attempts
+const (
+	reconnectBaseDelay = 1 * time.Second
+	reconnectMaxDelay  = 30 * time.Second
+)
 
 func main() {
+	// Exit with exitCode once every other deferred cleanup has run
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
 	// Parse command-line arguments
 	flag.StringVar(&logFileName, "log", "", "Specify a CSV file to log measured data")
-	flag.Parse()
+	flag.StringVar(&setRange, "set-range", "", "Set the measurement range ("+strings.Join(gm1356.ValidRanges(), ", ")+")")
+	flag.StringVar(&weighting, "weighting", "", "Set the frequency weighting (dBA or dBC)")
+	flag.BoolVar(&fastMode, "fast", false, "Set fast response (--fast=false selects slow); default keeps the device setting")
+	flag.BoolVar(&slowMode, "slow", false, "Set slow response")
+	flag.BoolVar(&setMaxHold, "set-maxhold", false, "Turn max-hold on (--set-maxhold=false turns it off); default keeps the device setting")
+	flag.BoolVar(&reconnect, "reconnect", true, "Reopen the device after repeated read errors (e.g. when it is unplugged)")
+	flag.IntVar(&maxRetries, "max-retries", 0, "Maximum reconnect attempts before giving up (0 = retry forever)")
+	flag.DurationVar(&retryBase, "retry-base", 500*time.Millisecond, "Delay before retrying a failed read, doubled (with jitter) for each further consecutive failure")
+	flag.DurationVar(&retryMax, "retry-max", 10*time.Second, "Longest delay between retries of failed reads")
+	flag.StringVar(&format, "format", formatJSON, "Output format: json, ndjson, value, or value-with-unit (all but json print the session summary on stderr so stdout is only readings)")
+	flag.BoolVar(&jsonPretty, "json-pretty", false, "Indent the JSON printed on stdout for reading by eye (--format json only; files stay compact)")
+	flag.BoolVar(&quiet, "quiet", false, "Only log warnings and errors; with --log, print nothing on stdout at all")
+	flag.BoolVar(&verbose, "verbose", false, "Log debug output such as sent commands and raw HID packets (same as --log-level debug)")
+	flag.DurationVar(&interval, "interval", 500*time.Millisecond, "Delay between readings, on top of --poll-delay")
+	flag.DurationVar(&commandDelay, "command-delay", gm1356.DefaultCommandDelay, "Time the device is given to process each config command, such as --set-range, --weighting, --fast/--slow, or --set-maxhold")
+	flag.DurationVar(&pollDelay, "poll-delay", 0, "Settle time after each capture command before the answer is read (0 = read straight away; raise it if readings repeat or fail)")
+	flag.BoolVar(&noOpCommand, "no-op-command", false, "Send the capture command once and then just read the packets the device streams, falling back to commanding every read if they dry up")
+	flag.IntVar(&recapture, "recapture-every", 0, "With --no-op-command, resend the capture command after this many streamed reads (0 = only when the stream dries up)")
+	flag.StringVar(&promAddr, "prometheus", "", "Serve Prometheus metrics on /metrics at this address (e.g. :9101)")
+	flag.StringVar(&mqttBroker, "mqtt-broker", "", "Publish readings to this MQTT broker (e.g. tcp://localhost:1883)")
+	flag.StringVar(&mqttTopic, "mqtt-topic", "usb-decibel-meter/reading", "MQTT topic readings are published to")
+	flag.StringVar(&mqttUsername, "mqtt-username", "", "MQTT username")
+	flag.StringVar(&mqttPassword, "mqtt-password", "", "MQTT password")
+	flag.UintVar(&mqttQoS, "mqtt-qos", 0, "MQTT quality of service level (0, 1, or 2)")
+	flag.BoolVar(&summary, "summary", true, "Print min/max/mean statistics for the session on exit")
+	flag.BoolVar(&leq, "leq", false, "Print the equivalent continuous sound level (Leq) for the session on exit")
+	flag.DurationVar(&leqWindow, "leq-window", 0, "Add a rolling Leq over this window to each reading (e.g. 1m)")
+	flag.Float64Var(&calibration, "calibration", 0.0, "Offset in dB added to every reading (e.g. 2.3 for a meter that reads 2.3 dB low)")
+	flag.Float64Var(&acOffset, "ac-offset", 0, "Add an estimate of the other weighting to every JSON record, assuming dBC is this many dB above dBA (0 = off)")
+	flag.StringVar(&serial, "serial", "", "Open the meter with this serial number instead of the first one found; a comma-separated list reads several meters at once")
+	flag.Float64Var(&threshold, "threshold", 0, "Alert when the level stays above this many dB (0 = disabled)")
+	flag.DurationVar(&thresholdFor, "threshold-duration", 0, "How long the level must stay above --threshold before alerting")
+	flag.StringVar(&onAlert, "on-alert", "", "Shell command to run when an alert fires (DECIBEL_MEASURED is set in its environment)")
+	flag.StringVar(&sqlitePath, "sqlite", "", "Specify a SQLite database to insert measured data into")
+	flag.DurationVar(&duration, "duration", 0, "Stop after running for this long (e.g. 60s); 0 runs until interrupted")
+	flag.StringVar(&heartbeatFile, "heartbeat-file", "", "Touch this file on every successful reading, so a watchdog can alert when its mtime goes stale")
+	flag.DurationVar(&heartbeatLog, "heartbeat-interval", 0, "Log a heartbeat line with the number of readings this often (e.g. 1m; 0 = never)")
+	flag.Float64Var(&minValid, "min-valid", 20, "Drop readings below this many dB as corrupt packets (the meter can't measure below 30 dB)")
+	flag.Float64Var(&maxValid, "max-valid", 140, "Drop readings above this many dB as corrupt packets (the meter can't measure above 130 dB; 0 disables this upper bound)")
+	flag.IntVar(&warmup, "warmup", 0, "Read and discard this many samples after opening or reconnecting the device, which may still show a stale value")
+	flag.IntVar(&sampleCount, "count", 0, "Stop after this many successful readings; 0 reads until interrupted")
+	flag.StringVar(&wsAddr, "websocket", "", "Stream readings over a WebSocket on /ws at this address (e.g. :8080)")
+	flag.StringVar(&tcpAddr, "tcp", "", "Stream one reading per line, as JSON or in the --format value formats, to every client of a plain TCP server at this address (e.g. :9000)")
+	flag.StringVar(&unixSocket, "unix-socket", "", "Stream readings as NDJSON to every client of a Unix socket at this path (e.g. /run/meter.sock)")
+	flag.StringVar(&httpAddr, "http", "", "Serve the latest reading on GET /reading at this address (e.g. :8080)")
+	flag.StringVar(&logLevel, "log-level", "info", "Diagnostic log level: debug, info, warn, or error")
+	flag.StringVar(&influxURL, "influx-url", "", "Write readings to the InfluxDB v2 server at this URL (e.g. http://localhost:8086)")
+	flag.StringVar(&influxBucket, "influx-bucket", "", "InfluxDB bucket to write to")
+	flag.StringVar(&influxToken, "influx-token", "", "InfluxDB API token")
+	flag.StringVar(&influxOrg, "influx-org", "", "InfluxDB organization")
+	flag.StringVar(&statsdAddr, "statsd-addr", "", "Send each reading as a decibel.measured gauge to the StatsD server at this UDP address (e.g. localhost:8125)")
+	flag.BoolVar(&statsdDog, "statsd-dogstatsd", false, "Tag StatsD gauges with the mode, weighting, range, and metadata in DogStatsD style")
+	flag.Int64Var(&logMaxSize, "log-max-size", 0, "Rotate the CSV log and --output file once they reach this many bytes (0 = never)")
+	flag.DurationVar(&logMaxAge, "log-max-age", 0, "Rotate the CSV log and --output file once they are this old (e.g. 24h; 0 = never)")
+	flag.BoolVar(&resume, "resume", false, "When appending to an existing CSV log, log how long the meter was offline since its last row")
+	flag.DurationVar(&resumeGap, "resume-gap", time.Minute, "With --resume, a time since the last row longer than this is warned about as an outage")
+	flag.BoolVar(&resumeMarker, "resume-marker", false, "With --resume, mark an outage with a row holding only the timestamp, which plotting tools show as a break")
+	flag.StringVar(&outputFile, "output", "", "Also write every reading as NDJSON to this file (- for stdout), independent of --log")
+	flag.BoolVar(&simulate, "simulate", false, "Feed synthetic readings through the pipeline instead of reading the device")
+	flag.StringVar(&simProfile, "simulate-profile", gm1356.ProfileNoisy, "Simulation profile: "+strings.Join(gm1356.SimulationProfiles(), ", "))
+	flag.BoolVar(&localTime, "local-time", false, "Timestamp readings in the local time zone instead of UTC")
+	flag.StringVar(&timezone, "timezone", "", "Timestamp readings in this IANA time zone (e.g. Europe/Berlin) instead of UTC")
+	flag.StringVar(&tsFormat, "timestamp-format", timestampDefault, "Timestamp format for JSON and CSV: default, rfc3339, or unix (epoch milliseconds)")
+	flag.DurationVar(&roundTo, "round-to-interval", 0, "Snap each emitted timestamp to the nearest multiple of this duration (e.g. 1s), so rows from several meters or runs line up")
+	flag.IntVar(&smoothWindow, "smooth", 0, "Add a moving average over the last N readings as the smoothed field (0 = off)")
+	flag.IntVar(&decimate, "decimate", 0, "Output only one reading in every K, while statistics, Leq, and alerts still see every reading (0 or 1 = output all)")
+	flag.BoolVar(&deviceHeader, "device-header", false, "In ndjson mode, print a device info line (manufacturer, product, serial) before the first reading")
+	flag.StringVar(&percentiles, "percentiles", "", "Print the levels exceeded this percentage of the time on exit (e.g. 10,50,90 for L10/L50/L90)")
+	flag.BoolVar(&waitForDevice, "wait-for-device", false, "If no meter is attached at startup, wait for one to be plugged in instead of exiting")
+	flag.BoolVar(&includeRaw, "include-raw", false, "Add the hex-encoded HID packet behind each reading as the raw field (JSON) and column (CSV)")
+	flag.BoolVar(&dedup, "dedup", false, "Skip readings whose packet is identical to the previous one, as when reading faster than the device updates; repeats are flagged stale in the JSON either way")
+	flag.BoolVar(&excludeOutOfRange, "exclude-out-of-range", false, "Leave readings flagged outOfRange out of the statistics (summary, Leq, percentiles, peaks, and --aggregate windows); they are still output, and the CSV log gets an outOfRange column")
+	flag.BoolVar(&selfTest, "self-test", false, "Check that the meter answers the capture command with a plausible reading, print a PASS/FAIL report, and exit")
+	flag.StringVar(&fieldList, "fields", "", "Comma-separated reading fields to output, in order, as JSON keys and CSV columns (e.g. timestamp,measured,range; default all)")
+	flag.BoolVar(&tuiMode, "tui", false, "Show a live full-screen display instead of printing JSON (q or Ctrl-C quits)")
+	flag.Float64Var(&tuiThreshold, "tui-threshold", 85, "Levels above this many dB are shown in red in the --tui display")
+	flag.StringVar(&profileName, "profile", gm1356.ProfileGM1356.Name, "Meter model, selecting its USB IDs, commands, and config byte layout: "+strings.Join(gm1356.Profiles(), ", "))
+	flag.Var(&vendorID, "vendor-id", "USB vendor `ID` in hex, for compatible clones that enumerate under other IDs")
+	flag.Var(&productID, "product-id", "USB product `ID` in hex, for compatible clones that enumerate under other IDs")
+	flag.BoolVar(&listDevices, "list-devices", false, "List attached meters (path, serial, manufacturer, product) and exit; with --vendor-id 0 list every HID device")
+	flag.DurationVar(&aggregate, "aggregate", 0, "Print and log one min/max/mean summary per window of this length (e.g. 10s) instead of every reading")
+	flag.StringVar(&otelEndpoint, "otel-endpoint", "", "Export OpenTelemetry metrics over OTLP/HTTP to this endpoint (e.g. http://localhost:4318)")
+	flag.StringVar(&location, "location", "", "Tag every record with this location (e.g. kitchen)")
+	flag.StringVar(&hostname, "hostname", "", "Tag every record with this hostname (default with --append-metadata: the system hostname)")
+	flag.BoolVar(&appendMeta, "append-metadata", false, "Tag every record with the hostname of the machine running the meter")
+	flag.Var(tags, "tag", "Tag every record with a `key=value` pair; may be repeated")
+	flag.StringVar(&decodeVariant, "decode-variant", string(gm1356.VariantStandard), "How to decode the level bytes, for firmware variants: "+strings.Join(gm1356.DecodeVariants(), ", "))
+	flag.DurationVar(&readTimeout, "read-timeout", gm1356.DefaultReadTimeout, "Give up on a read after this long so a hung device is retried or reconnected (0 = wait forever)")
+	flag.StringVar(&grpcAddr, "grpc", "", "Serve a gRPC reading stream and health check at this address (e.g. :50051)")
+	flag.BoolVar(&once, "once", false, "Print a single reading and exit, with only warnings and errors logged")
+	flag.BoolVar(&allDevices, "all-devices", false, "Read every attached meter at once, merging their readings into one stream tagged with the serial number")
+	flag.StringVar(&pidPath, "pidfile", "", "Write the process ID to this file and hold a lock on it, refusing to start if another instance holds it")
+	flag.IntVar(&precision, "precision", 1, "Decimal places for levels in JSON, CSV, and plain-value output (the device resolves 0.1 dB)")
+	flag.BoolVar(&useSyslog, "syslog", false, "Send each reading as a JSON message to the local syslog daemon")
+	flag.StringVar(&syslogAddr, "syslog-addr", "", "Send readings to this remote syslog server instead (e.g. udp://logs:514 or tcp://logs:514); implies --syslog")
+	flag.StringVar(&syslogFacil, "syslog-facility", "local0", "Syslog facility: user, daemon, or local0-local7")
+	flag.StringVar(&syslogSev, "syslog-severity", "info", "Syslog severity of readings; those above --threshold are sent as warning")
+	flag.StringVar(&csvDelimiter, "csv-delimiter", ",", "Field delimiter for the CSV log: a single character such as ; or \"tab\"")
+	flag.BoolVar(&csvNoHeader, "no-header", false, "Don't write a header row at the top of new CSV log files")
+	flag.BoolVar(&csvCRLF, "csv-crlf", false, "End CSV log lines with \\r\\n (Windows-style) instead of \\n")
+	flag.BoolVar(&csvEpoch, "csv-epoch", false, "Add an epochMs column to the CSV log with the timestamp in Unix milliseconds, e.g. for Grafana's CSV data source")
+	flag.BoolVar(&decodeBattery, "decode-battery", false, "Decode the battery-low indicator (unconfirmed config bit 0x08) and warn when it comes on")
+	flag.StringVar(&replayFile, "replay", "", "Re-emit the readings of a CSV log written by --log through the outputs instead of reading the device")
+	flag.BoolVar(&replayRealtm, "replay-realtime", false, "With --replay, keep the original gaps between readings instead of replaying as fast as possible")
+	flag.BoolVar(&strict, "strict", false, "Drop readings whose config byte has an unrecognized range instead of emitting them with range \"unknown\"")
+	flag.BoolVar(&haDiscover, "ha-discovery", false, "Announce each meter as a Home Assistant sensor over MQTT and publish its readings to the sensor's state topic (requires --mqtt-broker)")
+	flag.StringVar(&haPrefix, "ha-prefix", "homeassistant", "Home Assistant MQTT discovery prefix")
+	flag.DurationVar(&flushEvery, "flush-interval", 0, "Buffer log writes and flush them this often (e.g. 1s) instead of after every reading; also sets the SQLite and InfluxDB batch interval")
+	flag.IntVar(&flushRows, "flush-rows", 0, "Buffer log writes and flush them every this many rows; also sets the SQLite and InfluxDB batch size")
+	flag.StringVar(&dashboardAddr, "dashboard", "", "Serve a live chart of the readings in the browser at this address (e.g. :8080)")
+	flag.DurationVar(&burstFor, "burst-duration", 0, "When a reading reaches --burst-trigger-threshold, read back to back without the --interval delay for this long (e.g. 5s)")
+	flag.Float64Var(&burstTrigger, "burst-trigger-threshold", 0, "Level in dB that starts a --burst-duration fast-sampling window")
+	flag.BoolVar(&stdinRaw, "stdin-raw", false, "Decode hex-encoded 8-byte HID packets read one per line from stdin instead of reading the device")
+	flag.StringVar(&configFile, "config", "", "Read default option values from this JSON file (command-line flags take precedence)")
+	flag.Usage = usage
+	command, args := splitCommand(os.Args[1:])
+	flag.CommandLine.Parse(args)
+	if configFile != "" {
+		if err := loadConfigFile(configFile); err != nil {
+			usageError("Failed to load config file", "err", err)
+		}
+	}
+
+	// Diagnostics go through slog to stderr; readings stay on stdout
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		usageError("Invalid --log-level (valid choices: debug, info, warn, error)", "level", logLevel)
+	}
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet, once:
+		level = slog.LevelWarn
+	}
+	logOptions := &slog.HandlerOptions{Level: level}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, logOptions)))
+
+	var err error
+	if profile, err = gm1356.LookupProfile(profileName); err != nil {
+		usageError("Invalid --profile", "err", err)
+	}
+	ids := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { ids[f.Name] = true })
+	if !ids["vendor-id"] {
+		vendorID = hexID(profile.VendorID)
+	}
+	if !ids["product-id"] {
+		productID = hexID(profile.ProductID)
+	}
+
+	if listDevices {
+		if err := printDevices(os.Stdout); err != nil {
+			fatal("Failed to list devices", "err", err)
+		}
+		return
+	}
+
+	if interval < 0 || sampleCount < 0 || warmup < 0 || smoothWindow < 0 || decimate < 0 || aggregate < 0 || logMaxSize < 0 || logMaxAge < 0 || thresholdFor < 0 || duration < 0 || leqWindow < 0 || commandDelay < 0 || pollDelay < 0 || recapture < 0 || readTimeout < 0 || precision < 0 || flushEvery < 0 || flushRows < 0 || burstFor < 0 || heartbeatLog < 0 || retryBase < 0 || retryMax < 0 || roundTo < 0 || resumeGap < 0 {
+		usageError("Invalid flags: --interval, --command-delay, --poll-delay, --leq-window, --threshold-duration, --duration, --count, --warmup, --log-max-size, --log-max-age, --smooth, --decimate, --aggregate, --read-timeout, --recapture-every, --precision, --flush-interval, --flush-rows, --burst-duration, --heartbeat-interval, --retry-base, --retry-max, --round-to-interval, and --resume-gap must not be negative")
+	}
+	if burstFor > 0 && burstTrigger <= 0 {
+		usageError("Invalid flags: --burst-duration requires --burst-trigger-threshold")
+	}
+	if influxURL != "" && (influxBucket == "" || influxOrg == "") {
+		usageError("Invalid flags: --influx-url requires --influx-bucket and --influx-org")
+	}
+	if (resume || resumeMarker) && logFileName == "" {
+		usageError("Invalid flags: --resume and --resume-marker require --log")
+	}
+	if haDiscover && mqttBroker == "" {
+		usageError("Invalid flags: --ha-discovery requires --mqtt-broker")
+	}
+	if mqttQoS > 2 {
+		usageError("Invalid --mqtt-qos (valid choices: 0, 1, 2)", "qos", mqttQoS)
+	}
+	if quiet && verbose {
+		usageError("Invalid flags: --quiet and --verbose are mutually exclusive")
+	}
+
+	switch format {
+	case formatJSON:
+	case formatNDJSON, formatValue, formatValueUnit:
+		statusOut = os.Stderr
+	default:
+		usageError("Invalid --format (valid choices: json, ndjson, value, value-with-unit)", "format", format)
+	}
+	if maxValid != 0 && minValid >= maxValid {
+		usageError("Invalid flags: --min-valid must be below --max-valid", "min", minValid, "max", maxValid)
+	}
+	if math.IsNaN(calibration) || math.IsInf(calibration, 0) {
+		usageError("Invalid --calibration: must be a finite number of dB", "calibration", calibration)
+	}
+	if math.IsNaN(acOffset) || math.IsInf(acOffset, 0) {
+		usageError("Invalid --ac-offset: must be a finite number of dB", "ac-offset", acOffset)
+	}
+	if jsonPretty && format != formatJSON {
+		usageError("Invalid flags: --json-pretty only applies to --format json, the other formats are one line per reading")
+	}
+	if quiet {
+		statusOut = io.Discard
+	}
+
+	switch tsFormat {
+	case timestampDefault, timestampRFC3339, timestampUnix:
+	default:
+		usageError("Invalid --timestamp-format (valid choices: default, rfc3339, unix)", "format", tsFormat)
+	}
 
-	// Initialize HIDAPI
-	if err := hid.Init(); err != nil {
-		log.Fatalf("Failed to initialize HIDAPI: %v", err)
+	comma, err := parseCSVDelimiter(csvDelimiter)
+	if err != nil {
+		usageError("Invalid --csv-delimiter", "err", err)
+	}
+
+	if fieldList != "" {
+		if aggregate > 0 {
+			usageError("Invalid flags: --fields selects reading fields, which --aggregate window summaries don't have")
+		}
+		if fields, err = parseFields(fieldList); err != nil {
+			usageError("Invalid --fields", "err", err)
+		}
+		if excludeOutOfRange && logFileName != "" && !slices.Contains(fields, "outOfRange") {
+			usageError("Invalid flags: --exclude-out-of-range keeps excluded readings in the CSV log flagged by an outOfRange column, add it to --fields")
+		}
+		if (resume || resumeMarker) && !slices.Contains(fields, "timestamp") {
+			usageError("Invalid flags: --resume and --resume-marker need a timestamp column, add it to --fields")
+		}
+	}
+
+	variant, err := gm1356.ParseDecodeVariant(decodeVariant)
+	if err != nil {
+		usageError("Invalid --decode-variant", "err", err)
 	}
-	defer hid.Exit()
 
-	// Open GM1356 Device
-	device, err := hid.OpenFirst(vendorID, productID)
+	clock, err := timestampClock()
 	if err != nil {
-		log.Fatalf("Failed to open device: %v", err)
+		usageError("Invalid --timezone", "err", err)
+	}
+
+	meta = metadata{Location: location, Hostname: hostname}
+	if meta.Hostname == "" && appendMeta {
+		if meta.Hostname, err = os.Hostname(); err != nil {
+			fatal("Failed to look up hostname, set it with --hostname", "err", err)
+		}
+	}
+	if len(tags) > 0 {
+		meta.Tags = tags
+	}
+
+	// Validate requested settings before touching the device
+	settings := gm1356.Settings{Range: setRange, FreqMode: weighting}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "fast":
+			settings.Fast = &fastMode
+		case "slow":
+			if slowMode {
+				fast := false
+				settings.Fast = &fast
+			}
+		case "set-maxhold":
+			settings.MaxHold = &setMaxHold
+		}
+	})
+	if fastMode && slowMode {
+		usageError("Invalid flags: --fast and --slow are mutually exclusive")
+	}
+	if err := profile.Validate(settings); err != nil {
+		usageError("Invalid settings", "err", err)
+	}
+	switch {
+	case command == commandConfig && settings.Empty():
+		usageError("Invalid flags: config needs at least one of --set-range, --weighting, --fast, --slow, or --set-maxhold")
+	case command == commandInfo && !settings.Empty():
+		usageError("Invalid flags: info only describes the device, change settings with the config command")
+	case command != commandRead && (replayFile != "" || stdinRaw):
+		usageError("Invalid flags: --replay and --stdin-raw have no device for the " + command + " command")
+	case selfTest && (command != commandRead || !settings.Empty() || replayFile != "" || stdinRaw):
+		usageError("Invalid flags: --self-test checks an attached (or --simulate) meter as it is, without a command or device settings")
+	}
+	if replayFile != "" && (simulate || !settings.Empty()) {
+		usageError("Invalid flags: --replay can't be combined with --simulate or device settings")
+	}
+	if stdinRaw && (simulate || replayFile != "" || !settings.Empty()) {
+		usageError("Invalid flags: --stdin-raw can't be combined with --simulate, --replay, or device settings")
+	}
+
+	// Refuse to start a second instance that would fight over the device
+	if pidPath != "" {
+		lock, err := acquirePidFile(pidPath)
+		if err != nil {
+			fatal("Failed to acquire --pidfile", "path", pidPath, "err", err)
+		}
+		defer lock.release()
+	}
+
+	// Handle graceful shutdown: the context is cancelled on SIGINT/SIGTERM, after --duration, or when the reader stops on its own
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go handleSignals(signals, cancel)
+
+	// Open the reading sources
+	var meters []source
+	if simulate {
+		simulator, err := gm1356.NewSimulator(simProfile)
+		if err != nil {
+			usageError("Invalid --simulate-profile", "err", err)
+		}
+		simulator.Calibration = calibration
+		simulator.Now = clock
+		simulator.IncludeRaw = includeRaw
+		meters = append(meters, simulator)
+		slog.Info("Simulating GM1356 Decibel Meter", "profile", simProfile)
+	} else if replayFile != "" {
+		replay, err := newReplaySource(replayFile, comma, replayRealtm)
+		if err != nil {
+			fatal("Failed to open --replay file", "err", err)
+		}
+		defer replay.Close()
+		meters = append(meters, replay)
+		interval = 0 // The file sets the pace
+		slog.Info("Replaying CSV log", "file", replayFile, "realtime", replayRealtm)
+	} else if stdinRaw {
+		meters = append(meters, newRawFrameSource(ctx, os.Stdin, profile, variant, decodeBattery, calibration, clock))
+		interval = 0 // The input sets the pace
+		slog.Info("Decoding raw frames from stdin")
+	} else {
+		if err := gm1356.Init(); err != nil {
+			fatalWith(exitDeviceNotFound, "Failed to initialize HIDAPI", "err", err)
+		}
+		defer gm1356.Exit()
+		serials, err := selectSerials()
+		if err != nil {
+			fatalWith(exitDeviceNotFound, "Failed to find devices", "err", err)
+		}
+		for _, serial := range serials {
+			device, err := openMeter(ctx, clock, serial)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if selfTest {
+					printOpenFailure(os.Stdout, serial, err)
+				}
+				if errors.Is(err, gm1356.ErrPermission) {
+					fmt.Fprint(os.Stderr, permissionHint(uint16(vendorID), uint16(productID)))
+				}
+				fatalWith(exitDeviceNotFound, "Failed to open device", "serial", serial, "err", err)
+			}
+			defer device.Close()
+			device.Profile = profile
+			device.Variant = variant
+			device.DecodeBattery = decodeBattery
+			meters = append(meters, device)
+		}
+	}
+	multiDevice = len(meters) > 1
+	if multiDevice && tuiMode {
+		usageError("Invalid flags: --tui shows a single meter, pick one with --serial")
+	}
+
+	if selfTest {
+		for _, meter := range meters {
+			if !runSelfTest(ctx, os.Stdout, meter) {
+				exitCode = exitReadFailure
+			}
+		}
+		return
 	}
-	defer device.Close()
-	fmt.Println("Connected to GM1356 Decibel Meter")
+
+	for _, meter := range meters {
+		logger := slog.Default()
+		if multiDevice {
+			logger = logger.With("serial", meter.Info().Serial)
+		}
+
+		// Read current mode, frequency mode, and range before starting measurement
+		config, err := meter.ReadConfig(ctx)
+		if ctx.Err() != nil {
+			return // Interrupted before the device answered
+		}
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue // Replayed or piped-in readings have no device to query or configure
+		}
+		if command == commandInfo {
+			printMeterInfo(os.Stdout, meter.Info(), config, err)
+			continue
+		}
+		if err != nil {
+			logger.Warn("Failed to read current mode, defaulting to unknown", "err", err)
+		} else {
+			logConfig(logger, "Current config", config)
+		}
+
+		// Apply all requested settings in a single config write
+		if !settings.Empty() {
+			config, err = meter.SetConfig(ctx, settings)
+			if ctx.Err() != nil {
+				return // Interrupted while the device was settling
+			}
+			if errors.Is(err, gm1356.ErrNotAccepted) {
+				fatalWith(exitReadFailure, "The device did not accept the requested settings; set them with the meter's buttons instead", "serial", meter.Info().Serial, "err", err)
+			}
+			if err != nil {
+				fatalWith(exitReadFailure, "Failed to configure device", "serial", meter.Info().Serial, "err", err)
+			}
+			logConfig(logger, "Device configured", config)
+		}
+	}
+
+	if command != commandRead {
+		return // config and info are done once the devices are configured or described
+	}
+
+	// Identify the devices at the top of a machine-readable stream so multiple meters can be told apart
+	if deviceHeader && format == formatNDJSON {
+		for _, meter := range meters {
+			header, _ := json.Marshal(struct {
+				DeviceInfo gm1356.DeviceInfo `json:"deviceInfo"`
+			}{meter.Info()})
+			fmt.Println(string(header))
+		}
+	}
+
+	flush := flushPolicy{interval: flushEvery, rows: flushRows}
 
 	// Open CSV log file if logging is enabled
-	var csvFile *os.File
-	var csvWriter *csv.Writer
+	var csvWriter *csvLog
 	if logFileName != "" {
-		csvFile, csvWriter, err = setupCSVLog(logFileName)
+		header := csvHeader
+		switch {
+		case aggregate > 0:
+			header = aggregateHeader
+		case fields != nil:
+			header = fields
+		case includeRaw:
+			header = append(slices.Clip(header), "raw")
+		}
+		if excludeOutOfRange && aggregate == 0 && fields == nil {
+			header = append(slices.Clip(header), "outOfRange")
+		}
+		if multiDevice && fields == nil {
+			header = append(slices.Clip(header), "serial")
+		}
+		if csvEpoch {
+			header = append(slices.Clip(header), "epochMs")
+		}
+		header = append(slices.Clip(header), meta.labelNames()...)
+		dialect := csvDialect{comma: comma, noHeader: csvNoHeader, crlf: csvCRLF}
+
+		// Find the last logged row before appending to the file, so the outage since then can be reported
+		var lastLogged time.Time
+		if resume || resumeMarker {
+			var found bool
+			lastLogged, found, err = lastLoggedTime(logFileName, comma, slices.Index(header, "timestamp"), clock().Location())
+			if err != nil {
+				slog.Warn("Failed to find the last row of the log file, resuming without gap detection", "file", logFileName, "err", err)
+			} else if !found {
+				slog.Info("No earlier rows in the log file, nothing to resume", "file", logFileName)
+			}
+		}
+
+		csvWriter, err = setupCSVLog(logFileName, header, dialect, flush, logMaxSize, logMaxAge)
 		if err != nil {
-			log.Fatalf("Failed to open log file: %v", err)
+			fatal("Failed to open log file", "err", err)
+		}
+		if !lastLogged.IsZero() {
+			if err := csvWriter.resume(lastLogged, clock(), resumeGap, resumeMarker); err != nil {
+				fatal("Failed to write the gap marker to the log file", "err", err)
+			}
 		}
-		defer csvFile.Close()
+		defer func() {
+			if err := csvWriter.Close(); err != nil {
+				slog.Error("Failed to close log file", "err", err)
+			}
+		}()
 	}
 
-	// Read current mode, frequency mode, and range before starting measurement
-	currentMode, currentFreqMode, currentRange, err := readCurrentMode(device)
-	if err != nil {
-		log.Printf("Warning: Failed to read current mode. Defaulting to unknown. Error: %v", err)
+	// Open the NDJSON output file if enabled
+	var jsonOut *jsonLog
+	if outputFile != "" {
+		jsonOut, err = setupJSONLog(outputFile, flush, logMaxSize, logMaxAge)
+		if err != nil {
+			fatal("Failed to open output file", "err", err)
+		}
+		defer func() {
+			if err := jsonOut.Close(); err != nil {
+				slog.Error("Failed to close output file", "err", err)
+			}
+		}()
+	}
+
+	// Open SQLite database if enabled
+	var sqliteWriter *sqliteLog
+	if sqlitePath != "" {
+		sqliteWriter, err = setupSQLiteLog(sqlitePath, flush)
+		if err != nil {
+			fatal("Failed to open SQLite database", "err", err)
+		}
+		defer func() {
+			if err := sqliteWriter.close(); err != nil {
+				slog.Error("Failed to close SQLite database", "err", err)
+			}
+		}()
+	}
+
+	// Start the Prometheus endpoint if enabled
+	var promMetrics *metrics
+	if promAddr != "" {
+		registry := prometheus.NewRegistry()
+		promMetrics = newMetrics(prometheus.WrapRegistererWith(meta.labelMap(), registry), multiDevice)
+		server, err := startMetricsServer(promAddr, registry)
+		if err != nil {
+			fatal("Failed to start Prometheus endpoint", "err", err)
+		}
+		defer shutdownServer(server)
+		slog.Info("Serving Prometheus metrics", "addr", promAddr, "path", "/metrics")
+	}
+
+	// Start the OpenTelemetry exporter if enabled
+	var otel *otelMetrics
+	if otelEndpoint != "" {
+		otel, err = newOTelMetrics(ctx, otelEndpoint, multiDevice)
+		if err != nil {
+			fatal("Failed to set up OpenTelemetry exporter", "err", err)
+		}
+		defer func() {
+			if err := otel.shutdown(); err != nil {
+				slog.Error("Failed to shut down OpenTelemetry exporter", "err", err)
+			}
+		}()
+		slog.Info("Exporting OpenTelemetry metrics", "endpoint", otelEndpoint)
+	}
+
+	// Connect to syslog if enabled
+	var syslogOut *syslogOutput
+	if useSyslog || syslogAddr != "" {
+		syslogOut, err = newSyslogOutput(syslogAddr, syslogFacil, syslogSev, threshold)
+		if err != nil {
+			fatal("Failed to connect to syslog", "err", err)
+		}
+		defer syslogOut.close()
+		slog.Info("Sending readings to syslog", "addr", syslogAddr, "facility", syslogFacil)
+	}
+
+	// Connect to the MQTT broker if enabled
+	var publisher *mqttPublisher
+	if mqttBroker != "" {
+		publisher, err = newMQTTPublisher(mqttBroker, mqttTopic, mqttUsername, mqttPassword, byte(mqttQoS))
+		if err != nil {
+			fatal("Failed to connect to MQTT broker", "err", err)
+		}
+		defer publisher.close()
+		slog.Info("Publishing readings to MQTT", "broker", mqttBroker, "topic", mqttTopic)
+	}
+	var homeAssistant *haDiscovery
+	if haDiscover {
+		homeAssistant = &haDiscovery{publisher: publisher, prefix: haPrefix}
+		for _, meter := range meters {
+			if err := homeAssistant.announce(meter.Info()); err != nil {
+				fatal("Failed to announce the meter to Home Assistant", "err", err)
+			}
+		}
+		slog.Info("Announced meters to Home Assistant", "prefix", haPrefix)
+	}
+
+	// Start the InfluxDB writer if enabled
+	var influx *influxWriter
+	if influxURL != "" {
+		influx, err = newInfluxWriter(influxURL, influxOrg, influxBucket, influxToken, meta.labels(), flush)
+		if err != nil {
+			usageError("Invalid --influx-url", "err", err)
+		}
+		defer influx.close()
+		slog.Info("Writing readings to InfluxDB", "url", influxURL, "bucket", influxBucket)
+	}
+
+	// Start the StatsD sender if enabled
+	var statsd *statsdWriter
+	if statsdAddr != "" {
+		statsd, err = newStatsdWriter(statsdAddr, statsdDog, meta.labels())
+		if err != nil {
+			usageError("Invalid --statsd-addr", "err", err)
+		}
+		defer statsd.close()
+		slog.Info("Sending readings to StatsD", "addr", statsdAddr, "dogstatsd", statsdDog)
+	}
+
+	// Start the JSON feed if enabled, for the WebSocket, the dashboard, or the Unix socket
+	var jsonFeed *broadcaster[[]byte]
+	if wsAddr != "" || dashboardAddr != "" || unixSocket != "" {
+		jsonFeed = newBroadcaster[[]byte]()
+		defer jsonFeed.close()
+	}
+	if wsAddr != "" {
+		server, err := startWebSocketServer(wsAddr, jsonFeed)
+		if err != nil {
+			fatal("Failed to start WebSocket server", "err", err)
+		}
+		defer shutdownServer(server)
+		slog.Info("Streaming readings over WebSocket", "addr", wsAddr, "path", "/ws")
+	}
+	if dashboardAddr != "" {
+		var thresholds []float64
+		if threshold > 0 {
+			thresholds = append(thresholds, threshold)
+		}
+		server, err := startDashboard(dashboardAddr, jsonFeed, thresholds)
+		if err != nil {
+			fatal("Failed to start dashboard", "err", err)
+		}
+		defer shutdownServer(server)
+		slog.Info("Serving the live dashboard", "addr", dashboardAddr)
+	}
+	if unixSocket != "" {
+		listener, err := listenUnix(unixSocket)
+		if err != nil {
+			fatal("Failed to listen on Unix socket", "err", err)
+		}
+		defer startLineServer(listener, jsonFeed).close()
+		slog.Info("Streaming readings over a Unix socket", "path", unixSocket)
+	}
+
+	// The TCP server follows --format, so it has a feed of its own
+	var lineFeed *broadcaster[[]byte]
+	if tcpAddr != "" {
+		listener, err := net.Listen("tcp", tcpAddr)
+		if err != nil {
+			fatal("Failed to start TCP server", "err", err)
+		}
+		lineFeed = newBroadcaster[[]byte]()
+		defer lineFeed.close()
+		defer startLineServer(listener, lineFeed).close()
+		slog.Info("Streaming readings over TCP", "addr", listener.Addr().String(), "format", format)
+	}
+
+	// Start the gRPC service if enabled
+	var grpcFeed *broadcaster[*decibelpb.Reading]
+	if grpcAddr != "" {
+		grpcFeed = newBroadcaster[*decibelpb.Reading]()
+		server, err := startGRPCServer(grpcAddr, grpcFeed)
+		if err != nil {
+			fatal("Failed to start gRPC server", "err", err)
+		}
+		defer server.shutdown()
+		defer grpcFeed.close()
+		slog.Info("Streaming readings over gRPC", "addr", grpcAddr)
+	}
+
+	// Hold Lmax/Lmin for the outputs that show them
+	var peaks *peakHold
+	if httpAddr != "" || tuiMode {
+		peaks = newPeakHold()
+	}
+
+	// Start the REST API if enabled
+	var latest *latestReading
+	if httpAddr != "" {
+		latest = &latestReading{}
+		server, err := startHTTPServer(httpAddr, latest, peaks)
+		if err != nil {
+			fatal("Failed to start HTTP server", "err", err)
+		}
+		defer shutdownServer(server)
+		slog.Info("Serving the latest reading", "addr", httpAddr, "path", "/reading")
+	}
+
+	// Signal liveness to an external watchdog if enabled
+	var beats *heartbeat
+	if heartbeatFile != "" || heartbeatLog > 0 {
+		beats = &heartbeat{path: heartbeatFile}
+		if heartbeatLog > 0 {
+			go beats.log(ctx, heartbeatLog)
+		}
+	}
+
+	var stats *sessionStats
+	if summary {
+		stats = &sessionStats{}
+	}
+	var levels *percentileTracker
+	if percentiles != "" {
+		exceedance, err := parsePercentiles(percentiles)
+		if err != nil {
+			usageError("Invalid --percentiles", "err", err)
+		}
+		levels = &percentileTracker{levels: exceedance}
+	}
+	var windows *aggregator
+	if aggregate > 0 {
+		windows = &aggregator{window: aggregate}
+	}
+	var smoothing *smoother
+	if smoothWindow > 0 {
+		smoothing = newSmoother(smoothWindow)
+	}
+	var decimation *decimator
+	if decimate > 1 {
+		decimation = &decimator{every: decimate}
+	}
+	var alerts *alerter
+	if threshold > 0 {
+		alerts = &alerter{threshold: threshold, duration: thresholdFor, command: onAlert}
+	}
+	var leqStats *leqTracker
+	if leq || leqWindow > 0 {
+		leqStats = &leqTracker{window: leqWindow}
+	}
+
+	// Start the --duration countdown only once everything is set up
+	if duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+
+	// Take over the terminal last so setup errors still print normally; diagnostics move to the display's status line
+	var display *tui
+	if tuiMode {
+		display, err = newTUI(tuiThreshold, peaks)
+		if err != nil {
+			fatal("Failed to start the terminal display", "err", err)
+		}
+		slog.SetDefault(slog.New(slog.NewTextHandler(display, logOptions)))
+		if device, ok := meters[0].(*gm1356.Meter); ok {
+			device.Logger = slog.Default()
+		}
+		go display.run(cancel)
+	}
+
+	out := outputs{csvWriter: csvWriter, jsonOut: jsonOut, sqlite: sqliteWriter, metrics: promMetrics, otel: otel, mqtt: publisher, homeAssistant: homeAssistant, syslog: syslogOut, influx: influx, statsd: statsd, jsonFeed: jsonFeed, lineFeed: lineFeed, grpc: grpcFeed, latest: latest, peaks: peaks, smooth: smoothing, stats: stats, percentiles: levels, leq: leqStats, alerts: alerts, heartbeat: beats, tui: display, aggregate: windows, decimate: decimation, seq: new(uint64), emitLock: &sync.Mutex{}}
+	if once {
+		for _, meter := range meters {
+			data, err := readOnce(ctx, meter)
+			if err != nil {
+				slog.Error("Failed to read data", "serial", meter.Info().Serial, "err", err)
+				exitCode = exitReadFailure
+				continue
+			}
+			if !out.emit(data) {
+				exitCode = exitReadFailure
+			}
+		}
+		out.flush()
+		return
+	}
+
+	// Read each device in a separate goroutine, so one unplugged meter doesn't stall the others
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var readErr error
+	readers := make([]outputs, len(meters))
+	for i, meter := range meters {
+		readers[i] = out
+		if multiDevice {
+			readers[i] = out.forDevice()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := readDecibelData(ctx, meter, readers[i]); err != nil {
+				if multiDevice {
+					err = fmt.Errorf("%s: %w", meter.Info().Serial, err)
+				}
+				errMu.Lock()
+				readErr = errors.Join(readErr, err)
+				errMu.Unlock()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	// Wait for exit signal, then let the reader drain and flush its outputs before the deferred closes run
+	<-ctx.Done()
+	slog.Info("Exiting...")
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		display.close()
+		fatalWith(exitReadFailure, "Reader did not stop in time, the device may be stuck in a read", "timeout", shutdownTimeout)
+	}
+	if display != nil {
+		display.close()
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, logOptions)))
+	}
+	if readErr != nil {
+		slog.Error("Reader stopped", "err", readErr)
+		exitCode = exitReadFailure
+	} else if slices.ContainsFunc(readers, func(o outputs) bool { return o.alerts.fired() }) {
+		exitCode = exitAlert
+	}
+	stats.print(statusOut)
+	levels.print(statusOut)
+	if leq {
+		results := leqStats.session()
+		switch len(results) {
+		case 0:
+			fmt.Fprintln(statusOut, "Leq: 0.0 dB over 0 samples")
+		case 1:
+			fmt.Fprintf(statusOut, "Leq: %.1f dB over %d samples\n", results[0].level, results[0].count)
+		default:
+			// Never average across weightings, report each one on its own
+			for _, r := range results {
+				fmt.Fprintf(statusOut, "Leq (%s): %.1f dB over %d samples\n", r.freqMode, r.level, r.count)
+			}
+		}
+	}
+}
+
+// handleSignals cancels the run on the first SIGINT/SIGTERM and exits immediately on the second, in case shutdown hangs
+func handleSignals(signals <-chan os.Signal, cancel context.CancelFunc) {
+	<-signals
+	slog.Warn("Shutting down, press Ctrl-C again to force quit")
+	cancel()
+	<-signals
+	slog.Error("Forced quit")
+	os.Exit(forceQuitExitCode)
+}
+
+// usage prints the command-line synopsis and the flags shared by all commands
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [command] [flags]\n\n", filepath.Base(os.Args[0]))
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  read    Stream readings to stdout and the enabled outputs (default)")
+	fmt.Fprintln(out, "  config  Apply --set-range, --weighting, --fast/--slow, and --set-maxhold to the device and exit")
+	fmt.Fprintln(out, "  info    Print the device identification and current settings and exit")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// splitCommand separates a leading subcommand from the flags, defaulting to read so flag-only invocations keep working
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commandRead, args
+	}
+	switch args[0] {
+	case commandRead, commandConfig, commandInfo:
+		return args[0], args[1:]
+	}
+	usageError("Unknown command (valid choices: read, config, info)", "command", args[0])
+	return "", nil
+}
+
+// printMeterInfo writes the identification strings and decoded config byte of a meter for the info command
+func printMeterInfo(w io.Writer, info gm1356.DeviceInfo, config byte, configErr error) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "Manufacturer:\t%s\n", info.Manufacturer)
+	fmt.Fprintf(table, "Product:\t%s\n", info.Product)
+	fmt.Fprintf(table, "Serial:\t%s\n", info.Serial)
+	fmt.Fprintf(table, "Release:\t%s\n", info.Release)
+	if configErr != nil {
+		fmt.Fprintf(table, "Config:\tunavailable (%v)\n", configErr)
 	} else {
-		fmt.Printf("Current Mode: %s, Frequency Mode: %s, Range: %s\n", currentMode, currentFreqMode, currentRange)
+		fmt.Fprintf(table, "Config:\t%#02x\n", config)
+		fmt.Fprintf(table, "Mode:\t%s\n", profile.Mode(config))
+		fmt.Fprintf(table, "Weighting:\t%s\n", profile.FreqMode(config))
+		fmt.Fprintf(table, "Range:\t%s\n", profile.Range(config))
+		fmt.Fprintf(table, "Max-hold:\t%t\n", profile.MaxHold(config))
 	}
+	fmt.Fprintln(table)
+	table.Flush()
+}
 
-	// Handle graceful shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+// fatal logs an error and exits with status 1
+func fatal(msg string, args ...any) {
+	fatalWith(exitFailure, msg, args...)
+}
 
-	// Read data in a separate goroutine
-	go readDecibelData(device, stop, csvWriter)
+// usageError logs an invalid-usage error and exits with exitUsage
+func usageError(msg string, args ...any) {
+	fatalWith(exitUsage, msg, args...)
+}
 
-	// Wait for exit signal
-	<-stop
-	fmt.Println("\nExiting...")
+// fatalWith logs an error and exits with the given status
+func fatalWith(code int, msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(code)
 }
 
-// setupCSVLog opens the CSV file for logging and writes headers if the file is new.
-func setupCSVLog(filename string) (*os.File, *csv.Writer, error) {
-	fileExists := fileExists(filename)
+// shutdownServer gracefully stops an HTTP server, giving in-flight requests a moment to finish
+func shutdownServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to shut down HTTP server", "err", err)
+	}
+}
 
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// openMeter opens the GM1356 with the given serial (empty for the first one found), with --wait-for-device polling until it is plugged in or ctx is cancelled
+func openMeter(ctx context.Context, clock func() time.Time, serial string) (*gm1356.Meter, error) {
+	meter, err := gm1356.OpenDevice(uint16(vendorID), uint16(productID), serial)
+	if err != nil && waitForDevice && !errors.Is(err, gm1356.ErrPermission) { // Waiting won't fix permissions
+		slog.Info("Waiting for device...", "err", err)
+		err = retryWithBackoff(ctx, 0, func(attempt int) error {
+			meter, err = gm1356.OpenDevice(uint16(vendorID), uint16(productID), serial)
+			if err != nil {
+				slog.Debug("Device still not found", "attempt", attempt, "err", err)
+			}
+			return err
+		})
+	}
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	meter.CommandDelay = commandDelay
+	meter.PollDelay = pollDelay
+	meter.Streaming = noOpCommand
+	meter.RecaptureEvery = recapture
+	meter.ReadTimeout = readTimeout
+	meter.Calibration = calibration
+	meter.Now = clock
+	meter.IncludeRaw = includeRaw
+	meter.Logger = slog.Default()
+	info := meter.Info()
+	slog.Info("Connected to GM1356 Decibel Meter", "manufacturer", info.Manufacturer, "product", info.Product, "serial", info.Serial, "release", info.Release)
+	return meter, nil
+}
 
-	writer := csv.NewWriter(file)
-	if !fileExists {
-		// Write CSV header only if the file is new
-		writer.Write([]string{"timestamp", "measured", "mode", "freqMode", "range"})
-		writer.Flush()
+// printDevices writes a table of the attached devices matching --vendor-id and --product-id
+func printDevices(w io.Writer) error {
+	if err := gm1356.Init(); err != nil {
+		return err
 	}
-	return file, writer, nil
+	defer gm1356.Exit()
+
+	devices, err := gm1356.Enumerate(uint16(vendorID), uint16(productID))
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		fmt.Fprintln(w, "No matching devices found")
+		return nil
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PATH\tID\tSERIAL\tMANUFACTURER\tPRODUCT")
+	for _, device := range devices {
+		fmt.Fprintf(table, "%s\t%04x:%04x\t%s\t%s\t%s\n", device.Path, device.VendorID, device.ProductID, device.Serial, device.Manufacturer, device.Product)
+	}
+	return table.Flush()
 }
 
-// fileExists checks if a file exists
-func fileExists(filename string) bool {
-	_, err := os.Stat(filename)
-	return !os.IsNotExist(err)
+// hexID is a USB vendor or product ID flag written in hex, with or without a 0x prefix
+type hexID uint16
+
+// String formats the ID as 0x-prefixed hex
+func (id *hexID) String() string {
+	return fmt.Sprintf("%#04x", uint16(*id))
 }
 
-// readCurrentMode reads a single packet from the device to determine its mode, frequency mode, and range.
-func readCurrentMode(device *hid.Device) (string, string, string, error) {
-	buf := make([]byte, 8)
+// Set parses a hex ID such as 0x64bd or 64bd
+func (id *hexID) Set(value string) error {
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(value), "0x"), 16, 16)
+	if err != nil {
+		return fmt.Errorf("invalid USB ID %q (want 4 hex digits such as 0x64bd)", value)
+	}
+	*id = hexID(n)
+	return nil
+}
 
-	// Send capture command to request a data sample
-	if err := sendCommand(device, commandCapture); err != nil {
-		return "unknown", "unknown", "unknown", fmt.Errorf("failed to send initial capture command: %v", err)
+// selectSerials returns the serial numbers of the meters to open: every attached one with --all-devices, otherwise those listed in --serial
+func selectSerials() ([]string, error) {
+	if !allDevices {
+		var serials []string
+		for _, s := range strings.Split(serial, ",") {
+			serials = append(serials, strings.TrimSpace(s))
+		}
+		return serials, nil
 	}
 
-	// Read one data packet from the device
-	n, err := device.Read(buf)
-	if err != nil || n < 6 {
-		return "unknown", "unknown", "unknown", fmt.Errorf("failed to read initial data: %v", err)
+	devices, err := gm1356.Enumerate(uint16(vendorID), uint16(productID))
+	if err != nil {
+		return nil, err
 	}
+	var serials []string
+	for _, device := range devices {
+		if device.Serial == "" {
+			return nil, fmt.Errorf("device %s reports no serial number, so it cannot be told apart from the others", device.Path)
+		}
+		if !slices.Contains(serials, device.Serial) {
+			serials = append(serials, device.Serial)
+		}
+	}
+	if len(serials) == 0 {
+		return nil, errors.New("no matching devices attached")
+	}
+	return serials, nil
+}
+
+// timestampClock returns the clock used to timestamp readings, honoring --local-time and --timezone
+func timestampClock() (func() time.Time, error) {
+	switch {
+	case timezone != "":
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+		return func() time.Time { return time.Now().In(loc) }, nil
+	case localTime:
+		return time.Now, nil
+	}
+	return func() time.Time { return time.Now().UTC() }, nil
+}
+
+// logConfig logs the mode, frequency mode, range, and max-hold state decoded from a config byte
+func logConfig(logger *slog.Logger, msg string, config byte) {
+	logger.Info(msg, "mode", profile.Mode(config), "freqMode", profile.FreqMode(config), "range", profile.Range(config), "maxHold", profile.MaxHold(config))
+}
+
+// taggedReading is a reading as emitted, with the --location/--hostname/--tag metadata alongside the decoded fields
+type taggedReading struct {
+	gm1356.DecibelReading
+	metadata
+}
 
-	// Extract mode, frequency mode, and range
-	mode := parseMode(buf[2])
-	freqMode := parseFreqMode(buf[2])
-	rangeValue := parseRange(buf[2])
+// outputs bundles the optional destinations every reading is sent to; nil fields are disabled
+type outputs struct {
+	csvWriter     *csvLog
+	jsonOut       *jsonLog
+	sqlite        *sqliteLog
+	metrics       *metrics
+	otel          *otelMetrics
+	mqtt          *mqttPublisher
+	homeAssistant *haDiscovery
+	syslog        *syslogOutput
+	influx        *influxWriter
+	statsd        *statsdWriter
+	jsonFeed      *broadcaster[[]byte] // JSON records for WebSocket, dashboard, and socket clients
+	lineFeed      *broadcaster[[]byte] // Lines in --format for TCP clients
+	grpc          *broadcaster[*decibelpb.Reading]
+	latest        *latestReading
+	peaks         *peakHold
+	smooth        *smoother
+	stats         *sessionStats
+	percentiles   *percentileTracker
+	leq           *leqTracker
+	alerts        *alerter
+	heartbeat     *heartbeat
+	tui           *tui
+	aggregate     *aggregator // Replaces per-reading stdout and CSV output with window summaries
+	decimate      *decimator  // Thins the per-reading records; statistics still see every reading
+	seq           *uint64     // Count of readings emitted by this device, numbering each one
 
-	return mode, freqMode, rangeValue, nil
+	// emitLock serializes emit and flush, which are called from one reader goroutine per device
+	emitLock *sync.Mutex
 }
 
-// sendCommand sends an 8-byte command to the GM1356
-func sendCommand(device *hid.Device, command []byte) error {
-	n, err := device.Write(command)
-	if err != nil || n != 8 {
-		return fmt.Errorf("failed to send command (sent %d bytes): %v", n, err)
+// forDevice returns a copy of o with fresh per-device state for smoothing, aggregation, decimation, and alerts, sharing everything else
+func (o outputs) forDevice() outputs {
+	if o.smooth != nil {
+		o.smooth = newSmoother(len(o.smooth.energies))
 	}
-	time.Sleep(500 * time.Millisecond) // Wait for device to process command
-	fmt.Printf("Command sent: %X\n", command) // Debugging
-	return nil
+	if o.aggregate != nil {
+		o.aggregate = &aggregator{window: o.aggregate.window}
+	}
+	if o.decimate != nil {
+		o.decimate = &decimator{every: o.decimate.every}
+	}
+	if o.seq != nil {
+		o.seq = new(uint64)
+	}
+	if o.alerts != nil {
+		o.alerts = &alerter{threshold: o.alerts.threshold, duration: o.alerts.duration, command: o.alerts.command}
+	}
+	return o
 }
 
-// readDecibelData continuously reads and decodes data from the GM1356
-func readDecibelData(device *hid.Device, stop chan os.Signal, csvWriter *csv.Writer) {
-	buf := make([]byte, 8)
+// emit sends a reading to stdout and every enabled output, reporting false if it had to be dropped
+func (o outputs) emit(data gm1356.DecibelReading) bool {
+	o.emitLock.Lock()
+	defer o.emitLock.Unlock()
 
-	for {
-		select {
-		case <-stop:
-			return
-		default:
-			time.Sleep(500 * time.Millisecond) // Prevent excessive polling
+	// Snap only the reported time; the aggregate windows follow it, so a row and its window agree
+	if o.seq != nil {
+		*o.seq++
+		data.Seq = *o.seq
+	}
+	if roundTo > 0 {
+		data.Time = data.Time.Round(roundTo)
+		data.Timestamp = data.Time.Format(gm1356.TimestampLayout)
+	}
+	if formatted, ok := formatTimestamp(data.Time, tsFormat); ok {
+		data.Timestamp = formatted
+	}
+	// Out-of-range levels aren't valid measurements, so --exclude-out-of-range keeps them out of the statistics but not the records
+	counted := !excludeOutOfRange || !data.OutOfRange
+	switch {
+	case o.leq == nil:
+	case counted:
+		data.Leq = o.leq.add(data.Time, data.Measured, data.FreqMode)
+	default:
+		data.Leq = o.leq.current(data.Time, data.FreqMode)
+	}
+	if o.smooth != nil {
+		data.Smoothed = o.smooth.add(data.Measured, data.FreqMode)
+	}
 
-			// Send capture command before reading data
-			if err := sendCommand(device, commandCapture); err != nil {
-				log.Printf("Error sending capture command: %v", err)
-				continue
+	// Round once here so every output agrees on the reported levels
+	data.Measured = roundLevel(data.Measured)
+	data.Leq = roundLevel(data.Leq)
+	data.Smoothed = roundLevel(data.Smoothed)
+	if acOffset != 0 {
+		data.Estimate = estimateOtherWeighting(data.Measured, data.FreqMode, acOffset)
+	}
+
+	// A NaN or infinite level, e.g. from a bad calibration or averaging bug, can't be encoded; skip the sample rather than print an empty record
+	jsonData, err := json.Marshal(taggedReading{data, meta})
+	if err == nil && fields != nil {
+		jsonData, err = selectJSON(jsonData, fields)
+	}
+	if err != nil {
+		slog.Error("Failed to encode reading, skipping it", "err", err, "measured", data.Measured, "leq", data.Leq, "smoothed", data.Smoothed, "serial", data.Serial)
+		return false
+	}
+
+	if o.aggregate != nil && counted {
+		if summary, done := o.aggregate.add(data); done {
+			o.writeSummary(summary)
+		}
+	}
+	if o.decimate.keep() {
+		o.record(data, jsonData)
+	}
+
+	o.metrics.observe(data)
+	o.otel.observe(data)
+	o.latest.set(data)
+	if counted {
+		o.peaks.add(data.Measured)
+		o.stats.add(data.Measured, data.FreqMode)
+		o.percentiles.add(data.Measured, data.FreqMode)
+	} else {
+		o.stats.addExcluded()
+	}
+	o.tui.update(data)
+	o.alerts.check(data.Time, data)
+	o.heartbeat.beat()
+	return true
+}
+
+// record writes one reading to stdout and the per-reading logs and feeds, the outputs --decimate thins
+func (o outputs) record(data gm1356.DecibelReading, jsonData []byte) {
+	if o.aggregate == nil && o.tui == nil && !o.jsonOut.toStdout() && (!quiet || o.csvWriter == nil) {
+		// Print one line per reading, unless the live display or --output - replaces it or quiet with the CSV log as the only output
+		fmt.Println(stdoutLine(data.Measured, data.FreqMode, jsonData))
+	}
+	if o.aggregate == nil {
+		if err := o.jsonOut.write(jsonData); err != nil {
+			slog.Error("Failed to write to output file", "err", err)
+		}
+	}
+
+	// Log data to CSV if enabled
+	if o.csvWriter != nil && o.aggregate == nil {
+		record := []string{data.Timestamp, formatLevel(data.Measured), data.Mode, data.FreqMode, data.Range}
+		switch {
+		case fields != nil:
+			record = fieldValues(data, fields)
+		case includeRaw:
+			record = append(record, data.Raw)
+		}
+		if excludeOutOfRange && fields == nil {
+			record = append(record, strconv.FormatBool(data.OutOfRange))
+		}
+		if multiDevice && fields == nil {
+			record = append(record, data.Serial)
+		}
+		if csvEpoch {
+			record = append(record, strconv.FormatInt(data.Time.UnixMilli(), 10))
+		}
+		record = append(record, meta.labelValues()...)
+		if err := o.csvWriter.Write(record); err != nil {
+			slog.Error("Failed to write to CSV log", "err", err)
+		}
+	}
+
+	// Insert into SQLite if enabled
+	if err := o.sqlite.write(data); err != nil {
+		slog.Error("Failed to write to SQLite database", "err", err)
+	}
+
+	o.mqtt.publish(jsonData)
+	o.homeAssistant.publishState(data, jsonData)
+	if err := o.syslog.write(jsonData, data.Measured); err != nil {
+		slog.Error("Failed to write to syslog", "err", err)
+	}
+	o.influx.write(data, data.Time)
+	o.statsd.write(data)
+	o.jsonFeed.publish(jsonData)
+	if o.lineFeed != nil {
+		o.lineFeed.publish(streamLine(data.Measured, data.FreqMode, jsonData))
+	}
+	if o.grpc != nil {
+		o.grpc.publish(toProtoReading(data))
+	}
+}
+
+// stdoutLine renders a record for stdout in the selected --format: the compact JSON object or just the level
+func stdoutLine(level float64, freqMode string, jsonData []byte) string {
+	switch format {
+	case formatValue:
+		return formatLevel(level)
+	case formatValueUnit:
+		return formatLevel(level) + " " + freqMode
+	}
+	if jsonPretty {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, jsonData, "", "  "); err == nil {
+			return indented.String()
+		}
+	}
+	return string(jsonData)
+}
+
+// streamLine renders a reading for a line-based stream client like stdoutLine, but always on a single line
+func streamLine(level float64, freqMode string, jsonData []byte) []byte {
+	if format == formatValue || format == formatValueUnit {
+		return []byte(stdoutLine(level, freqMode, jsonData))
+	}
+	return jsonData
+}
+
+// roundLevel rounds a level to --precision decimal places
+func roundLevel(level float64) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(level*scale) / scale
+}
+
+// formatLevel renders a level with exactly --precision decimal places
+func formatLevel(level float64) string {
+	return strconv.FormatFloat(level, 'f', precision, 64)
+}
+
+// flush writes out anything the outputs still have buffered
+func (o outputs) flush() {
+	o.emitLock.Lock()
+	defer o.emitLock.Unlock()
+
+	if o.aggregate != nil {
+		if summary, done := o.aggregate.drain(); done {
+			o.writeSummary(summary)
+		}
+	}
+	if o.csvWriter != nil {
+		o.csvWriter.Flush()
+		if err := o.csvWriter.Error(); err != nil {
+			slog.Error("Failed to flush CSV log", "err", err)
+		}
+	}
+	if err := o.jsonOut.Flush(); err != nil {
+		slog.Error("Failed to flush output file", "err", err)
+	}
+}
+
+// readDecibelData continuously reads and decodes data from the GM1356 until ctx is cancelled or --count readings were emitted
+func readDecibelData(ctx context.Context, meter source, out outputs) error {
+	defer out.flush()
+	consecutiveErrors := 0
+	emitted := 0
+	batteryLow := false
+	freqMode := ""                    // Weighting of the previous reading, to notice the button being pressed mid-session
+	unknownConfigs := map[byte]bool{} // Unrecognized config bytes already warned about
+	extraSeen := false                // Warned about data after the config byte
+	burst := burstCapture{trigger: burstTrigger, duration: burstFor}
+	bursting := false
+	errs := newErrorLog(meter.Info().Serial)
+	warming := warmup // Samples still to discard after opening the device
+
+	for {
+		if consecutiveErrors > 0 {
+			// Back off instead of hammering a flaky device at the full rate
+			if !sleepContext(ctx, jitter(backoff(retryBase, retryMax, consecutiveErrors))) {
+				return nil
+			}
+		} else if burst.active(time.Now()) {
+			// Read back to back, limited only by the device and --poll-delay
+			if !sleepContext(ctx, burstInterval) {
+				return nil
 			}
+		} else {
+			if bursting {
+				slog.Info("Burst capture finished, back to normal cadence", "serial", meter.Info().Serial)
+				bursting = false
+			}
+			if !sleepContext(ctx, interval) { // Prevent excessive polling
+				return nil
+			}
+		}
 
-			// Read HID response
-			n, err := device.Read(buf)
-			if err != nil {
-				log.Printf("Error reading data: %v", err)
+		data, err := meter.Read(ctx)
+		if ctx.Err() != nil {
+			return nil // Interrupted mid-read, not a device failure
+		}
+		if errors.Is(err, gm1356.ErrNoData) {
+			continue
+		}
+		if errors.Is(err, io.EOF) { // End of --replay or --stdin-raw input
+			return nil
+		}
+		if err != nil {
+			errs.failed(time.Now(), err)
+			out.metrics.observeError()
+			out.otel.observeError()
+			out.stats.addError()
+			consecutiveErrors++
+			if consecutiveErrors >= reconnectAfterErrors {
+				out.latest.markDisconnected()
+			}
+			if reconnect && consecutiveErrors >= reconnectAfterErrors {
+				if err := reconnectMeter(ctx, meter); err != nil {
+					return err
+				}
+				consecutiveErrors = 0
+				warming = warmup
+			}
+			continue
+		}
+		consecutiveErrors = 0
+		errs.recovered(time.Now())
+		if !plausible(data.RawMeasured) {
+			out.stats.addImplausible()
+			slog.Warn("Dropping implausible reading, probably a corrupt packet", "measured", data.RawMeasured, "min", minValid, "max", maxValid, "serial", data.Serial)
+			continue
+		}
+		if dedup && data.Stale {
+			out.stats.addStale()
+			slog.Debug("Skipping repeated packet", "measured", data.Measured, "serial", data.Serial)
+			continue
+		}
+		if warming > 0 {
+			warming--
+			slog.Debug("Discarded warm-up sample", "measured", data.Measured, "serial", data.Serial)
+			if warming == 0 {
+				slog.Info("Discarded warm-up samples", "count", warmup, "serial", data.Serial)
+			}
+			continue
+		}
+		if data.BatteryLow && !batteryLow {
+			slog.Warn("Meter battery is low, readings may become unreliable", "serial", data.Serial)
+		}
+		batteryLow = data.BatteryLow
+		if freqMode != "" && data.FreqMode != freqMode {
+			slog.Warn("Frequency weighting changed, statistics are kept separately per weighting", "from", freqMode, "to", data.FreqMode, "serial", data.Serial)
+		}
+		freqMode = data.FreqMode
+		if burst.check(data.Measured, time.Now()) {
+			slog.Info("Burst capture started", "measured", data.Measured, "for", burstFor, "serial", data.Serial)
+			bursting = true
+		}
+		if data.Extra != "" && !extraSeen {
+			extraSeen = true
+			slog.Warn("Packet carries data after the config byte, passed on as the extra field; please report it with your meter's model and firmware", "extra", data.Extra, "serial", data.Serial)
+		}
+		if data.UnknownConfig {
+			out.stats.addUnknownConfig()
+			if !unknownConfigs[data.Config] {
+				unknownConfigs[data.Config] = true
+				slog.Warn("Unrecognized config byte, range reported as unknown; please report it with your meter's model and firmware", "config", fmt.Sprintf("%#02x", data.Config), "serial", data.Serial, "dropped", strict)
+			}
+			if strict {
 				continue
 			}
+		}
+		if !out.emit(data) {
+			continue
+		}
 
-			if n > 0 {
-				// Debugging: print raw buffer
-				fmt.Printf("Raw Data Read (%d bytes): %v\n", n, buf)
+		emitted++
+		if sampleCount > 0 && emitted >= sampleCount {
+			return nil
+		}
+	}
+}
 
-				// Parse and print JSON data
-				data := parseDecibelData(buf)
-				jsonData, _ := json.Marshal(data)
-				fmt.Println(string(jsonData))
+// plausible reports whether a level as decoded from the device is within --min-valid and --max-valid; a zero --max-valid only lifts the upper bound
+func plausible(level float64) bool {
+	return level >= minValid && (maxValid == 0 || level <= maxValid)
+}
 
-				// Log data to CSV if enabled
-				if csvWriter != nil {
-					csvWriter.Write([]string{data.Timestamp, fmt.Sprintf("%.1f", data.Measured), data.Mode, data.FreqMode, data.Range})
-					csvWriter.Flush()
-				}
+// readOnce takes a single valid reading for --once after discarding any --warmup samples, retrying a couple of times if reads fail
+func readOnce(ctx context.Context, meter source) (gm1356.DecibelReading, error) {
+	var err error
+	discarded := 0
+	for attempt := 1; attempt <= onceAttempts; attempt++ {
+		var data gm1356.DecibelReading
+		data, err = meter.Read(ctx)
+		if ctx.Err() != nil {
+			return gm1356.DecibelReading{}, ctx.Err()
+		}
+		if err == nil && !plausible(data.RawMeasured) {
+			err = fmt.Errorf("implausible level %.1f dB, probably a corrupt packet", data.RawMeasured)
+		}
+		if err == nil && discarded == warmup {
+			return data, nil
+		}
+		if err == nil {
+			discarded++
+			attempt-- // Warm-up samples aren't failed attempts
+			slog.Debug("Discarded warm-up sample", "measured", data.Measured, "n", discarded)
+			if !sleepContext(ctx, interval) {
+				return gm1356.DecibelReading{}, ctx.Err()
 			}
+			continue
+		}
+		slog.Debug("Read failed, retrying", "attempt", attempt, "err", err)
+		if !sleepContext(ctx, interval) {
+			return gm1356.DecibelReading{}, ctx.Err()
 		}
 	}
+	return gm1356.DecibelReading{}, err
 }
 
-// parseDecibelData converts raw HID bytes into a structured format
-func parseDecibelData(buf []byte) DecibelReading {
-	// Extract decibel measurement (16-bit)
-	measured := float64((uint16(buf[0]) << 8) | uint16(buf[1])) / 10.0
-
-	// Determine mode, frequency mode, and range
-	mode := parseMode(buf[2])
-	freqMode := parseFreqMode(buf[2])
-	rangeStr := parseRange(buf[2])
+// reconnectMeter reopens the device with exponential backoff until it reappears, maxRetries is exhausted, or ctx is cancelled
+func reconnectMeter(ctx context.Context, meter source) error {
+	slog.Warn("Device not responding, reconnecting...")
+	err := retryWithBackoff(ctx, maxRetries, func(attempt int) error {
+		err := meter.Reopen()
+		if err != nil {
+			slog.Warn("Reconnect attempt failed", "attempt", attempt, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	slog.Info("Device reconnected")
+	return nil
+}
 
-	return DecibelReading{
-		Measured:  measured,
-		Mode:      mode,
-		FreqMode:  freqMode,
-		Range:     rangeStr,
-		Timestamp: time.Now().UTC().Format("2006-01-02 15:04:05 UTC"),
+// backoff returns base doubled for each failure after the first, capped at limit
+func backoff(base, limit time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && delay < limit; i++ {
+		delay *= 2
 	}
+	return min(delay, limit)
 }
 
-// parseMode decodes fast/slow mode from the HID buffer
-func parseMode(b byte) string {
-	if b&0x40 != 0 {
-		return "fast"
+// jitter spreads d randomly over [d/2, d], so meters that fail together don't retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d < 2 {
+		return d
 	}
-	return "slow"
+	return d/2 + rand.N(d/2)
 }
 
-// parseFreqMode decodes dBA/dBC mode from the HID buffer
-func parseFreqMode(b byte) string {
-	if b&0x10 != 0 || b&0x80 != 0 {
-		return "dBC"
+// retryWithBackoff calls try with exponential backoff and jitter until it succeeds, maxAttempts is exhausted (0 = retry forever), or ctx is cancelled
+func retryWithBackoff(ctx context.Context, maxAttempts int, try func(attempt int) error) error {
+	for attempt := 1; maxAttempts == 0 || attempt <= maxAttempts; attempt++ {
+		if !sleepContext(ctx, jitter(backoff(reconnectBaseDelay, reconnectMaxDelay, attempt))) {
+			return ctx.Err()
+		}
+		if err := try(attempt); err == nil {
+			return nil
+		}
 	}
-	return "dBA"
+	return fmt.Errorf("giving up after %d attempts", maxAttempts)
 }
 
-// parseRange extracts the measurement range from the HID buffer
-func parseRange(b byte) string {
-	if rangeStr, exists := rangeMap[b&0x0F]; exists {
-		return rangeStr
+// sleepContext waits for d, returning false early if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
 	}
-	return "unknown"
 }
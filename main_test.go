@@ -0,0 +1,305 @@
+//go:build unix
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so the tests can start the program as a child process
+const runMainEnv = "USB_DECIBEL_METER_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestInterruptFlushesLogs(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "readings.csv")
+	jsonPath := filepath.Join(dir, "readings.jsonl")
+
+	// Buffer everything until shutdown, so only the final flush can get the rows to disk
+	cmd := exec.Command(os.Args[0], "--simulate", "--interval", "10ms", "--format", "ndjson", "--summary=false",
+		"--log", csvPath, "--output", jsonPath, "--flush-rows", "100000", "--flush-interval", "1h")
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt once a few readings are out, then collect the rest until the program exits
+	var emitted []string
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		emitted = append(emitted, scanner.Text())
+		if len(emitted) == 20 {
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("program exited with %v", err)
+	}
+	if len(emitted) < 20 {
+		t.Fatalf("got %d readings before exit, want at least 20", len(emitted))
+	}
+
+	// Every line printed must be in the NDJSON file, complete and in order
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("%s does not end with a newline", jsonPath)
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); strings.Join(lines, "\n") != strings.Join(emitted, "\n") {
+		t.Errorf("%s has %d lines, want the %d printed on stdout", jsonPath, len(lines), len(emitted))
+	}
+
+	// And every reading must have a matching CSV row
+	file, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("%s is not valid CSV: %v", csvPath, err)
+	}
+	if len(rows) != len(emitted)+1 {
+		t.Fatalf("%s has %d rows, want a header and %d readings", csvPath, len(rows), len(emitted))
+	}
+	for i, line := range emitted {
+		var reading struct {
+			Timestamp string  `json:"timestamp"`
+			Measured  float64 `json:"measured"`
+		}
+		if err := json.Unmarshal([]byte(line), &reading); err != nil {
+			t.Fatalf("reading %d: %v", i, err)
+		}
+		row := rows[i+1]
+		measured, err := strconv.ParseFloat(row[1], 64)
+		if err != nil || row[0] != reading.Timestamp || measured != reading.Measured {
+			t.Errorf("row %d = %v, want timestamp %q and level %v", i+1, row, reading.Timestamp, reading.Measured)
+		}
+	}
+}
+
+// emitReadings sends readings through out.emit with stdout discarded and returns the records as published to stream clients
+func emitReadings(t *testing.T, out outputs, readings []gm1356.DecibelReading) []gm1356.DecibelReading {
+	t.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, savedPrecision := os.Stdout, precision
+	os.Stdout, precision = devNull, 1
+	defer func() {
+		os.Stdout, precision = stdout, savedPrecision
+		devNull.Close()
+	}()
+
+	out.jsonFeed = newBroadcaster[[]byte]()
+	defer out.jsonFeed.close()
+	records := out.jsonFeed.subscribe()
+	out.emitLock = &sync.Mutex{}
+
+	var emitted []gm1356.DecibelReading
+	for _, data := range readings {
+		if !out.emit(data) {
+			t.Fatalf("reading %+v was dropped", data)
+		}
+		var record gm1356.DecibelReading
+		if err := json.Unmarshal(<-records, &record); err != nil {
+			t.Fatal(err)
+		}
+		emitted = append(emitted, record)
+	}
+	return emitted
+}
+
+// testReading is a reading taken offset after a fixed start time, as a source would return it
+func testReading(offset time.Duration, measured float64) gm1356.DecibelReading {
+	at := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC).Add(offset)
+	return gm1356.DecibelReading{Time: at, Timestamp: at.Format(gm1356.TimestampLayout), Measured: measured, RawMeasured: measured, Mode: "slow", FreqMode: "dBA", Range: "30-130"}
+}
+
+func TestEmitUsesReadingClock(t *testing.T) {
+	// Replayed readings two minutes apart arrive back to back; the rolling Leq and alert duration must follow their timestamps
+	alerts := &alerter{threshold: 80, duration: 30 * time.Second}
+	out := outputs{leq: &leqTracker{window: time.Minute}, alerts: alerts}
+	got := emitReadings(t, out, []gm1356.DecibelReading{testReading(0, 90), testReading(2*time.Minute, 85), testReading(3*time.Minute, 85)})
+
+	// On the reading clock each earlier reading has left the one-minute window by the time the next arrives
+	for i, want := range []float64{90, 85, 85} {
+		if got[i].Leq != want {
+			t.Errorf("reading %d leq = %v, want %v", i+1, got[i].Leq, want)
+		}
+	}
+	if !alerts.fired() {
+		t.Error("alert didn't fire for readings above the threshold for longer than the duration")
+	}
+}
+
+func TestEmitExcludeOutOfRange(t *testing.T) {
+	defer func(saved bool) { excludeOutOfRange = saved }(excludeOutOfRange)
+	excludeOutOfRange = true
+
+	csvPath := filepath.Join(t.TempDir(), "readings.csv")
+	csvWriter, err := setupCSVLog(csvPath, append(slices.Clip(csvHeader), "outOfRange"), csvDialect{}, flushPolicy{}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, peaks := &sessionStats{}, newPeakHold()
+	levels := &percentileTracker{levels: []float64{90}, weighting: map[string]*reservoir{}}
+	out := outputs{csvWriter: csvWriter, leq: &leqTracker{window: time.Minute}, stats: stats, peaks: peaks, percentiles: levels}
+
+	clipped := testReading(time.Second, 135)
+	clipped.OutOfRange = true
+	got := emitReadings(t, out, []gm1356.DecibelReading{testReading(0, 60), clipped, testReading(2*time.Second, 60)})
+	if err := csvWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The clipped reading is still output, with the Leq of the readings around it
+	if !got[1].OutOfRange || got[1].Measured != 135 || got[1].Leq != 60 {
+		t.Errorf("clipped reading = %+v, want it output with leq 60", got[1])
+	}
+	if got[2].Leq != 60 {
+		t.Errorf("leq after the clipped reading = %v, want 60", got[2].Leq)
+	}
+	if l := stats.levels["dBA"]; stats.count != 2 || stats.excluded != 1 || l.max != 60 {
+		t.Errorf("session stats = %d samples, %d excluded, max %v, want 2, 1, 60", stats.count, stats.excluded, l.max)
+	}
+	if held := peaks.get(); held.Lmax != 60 || held.Samples != 2 {
+		t.Errorf("peak hold = %+v, want Lmax 60 over 2 samples", held)
+	}
+	if r := levels.weighting["dBA"]; r == nil || slices.Contains(r.samples, 135) {
+		t.Errorf("percentile samples = %+v, want the clipped level left out", r)
+	}
+
+	rows, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "timestamp,measured,mode,freqMode,range,outOfRange\n" +
+		"2025-03-01 05:04:00 UTC,60.0,slow,dBA,30-130,false\n" +
+		"2025-03-01 05:04:01 UTC,135.0,slow,dBA,30-130,true\n" +
+		"2025-03-01 05:04:02 UTC,60.0,slow,dBA,30-130,false\n"
+	if string(rows) != want {
+		t.Errorf("CSV log = %q, want %q", rows, want)
+	}
+}
+
+func TestPlausible(t *testing.T) {
+	defer func(savedMin, savedMax float64) { minValid, maxValid = savedMin, savedMax }(minValid, maxValid)
+	tests := []struct {
+		min, max float64
+		level    float64
+		want     bool
+	}{
+		{20, 140, 60, true},
+		{20, 140, 10, false},
+		{20, 140, 3276.7, false},
+		{20, 0, 3276.7, true}, // --max-valid 0 lifts only the upper bound
+		{20, 0, 10, false},
+		{0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		minValid, maxValid = tt.min, tt.max
+		if got := plausible(tt.level); got != tt.want {
+			t.Errorf("plausible(%v) with --min-valid %v --max-valid %v = %t, want %t", tt.level, tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkReadLoop measures the per-sample cost of the read loop against the simulator: decoding alone, then emitting to stdout, plus --output or the CSV log flushed per row or buffered
+func BenchmarkReadLoop(b *testing.B) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	stdout, savedPrecision := os.Stdout, precision
+	os.Stdout, precision = devNull, 1
+	b.Cleanup(func() {
+		os.Stdout, precision = stdout, savedPrecision
+		devNull.Close()
+	})
+
+	sim, err := gm1356.NewSimulator(gm1356.ProfileNoisy)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := sim.Read(b.Context()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	newCSV := func(b *testing.B, flush flushPolicy) *csvLog {
+		csvWriter, err := setupCSVLog(filepath.Join(b.TempDir(), "readings.csv"), csvHeader, csvDialect{comma: ','}, flush, 0, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { csvWriter.Close() })
+		return csvWriter
+	}
+	newJSON := func(b *testing.B) *jsonLog {
+		jsonOut, err := setupJSONLog(filepath.Join(b.TempDir(), "readings.jsonl"), flushPolicy{}, 0, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { jsonOut.Close() })
+		return jsonOut
+	}
+	tests := []struct {
+		name string
+		out  func(b *testing.B) outputs
+	}{
+		{"stdout", func(b *testing.B) outputs { return outputs{} }},
+		{"output-file", func(b *testing.B) outputs { return outputs{jsonOut: newJSON(b)} }},
+		{"csv", func(b *testing.B) outputs { return outputs{csvWriter: newCSV(b, flushPolicy{})} }},
+		{"csv-buffered", func(b *testing.B) outputs { return outputs{csvWriter: newCSV(b, flushPolicy{rows: 1000})} }},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			out := tt.out(b)
+			out.emitLock = &sync.Mutex{}
+			b.ReportAllocs()
+			for b.Loop() {
+				data, err := sim.Read(b.Context())
+				if err != nil {
+					b.Fatal(err)
+				}
+				if !out.emit(data) {
+					b.Fatal("reading was dropped")
+				}
+			}
+			out.flush()
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// metadata describes where the readings come from; it is attached to every record so several sensor nodes can share one database
+type metadata struct {
+	Location string            `json:"location,omitempty"`
+	Hostname string            `json:"hostname,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// labels returns the metadata as ordered key/value pairs for CSV columns, Influx tags, and Prometheus labels
+func (m metadata) labels() [][2]string {
+	var labels [][2]string
+	if m.Location != "" {
+		labels = append(labels, [2]string{"location", m.Location})
+	}
+	if m.Hostname != "" {
+		labels = append(labels, [2]string{"hostname", m.Hostname})
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.Tags)) {
+		labels = append(labels, [2]string{key, m.Tags[key]})
+	}
+	return labels
+}
+
+// labelMap returns labels as a map, e.g. for Prometheus constant labels
+func (m metadata) labelMap() map[string]string {
+	labelMap := map[string]string{}
+	for _, label := range m.labels() {
+		labelMap[label[0]] = label[1]
+	}
+	return labelMap
+}
+
+// labelNames returns the keys of labels, in the same order
+func (m metadata) labelNames() []string {
+	var names []string
+	for _, label := range m.labels() {
+		names = append(names, label[0])
+	}
+	return names
+}
+
+// labelValues returns the values of labels, in the same order
+func (m metadata) labelValues() []string {
+	var values []string
+	for _, label := range m.labels() {
+		values = append(values, label[1])
+	}
+	return values
+}
+
+// tagKeyPattern restricts tag keys to names that are valid Prometheus labels and Influx tag keys without escaping
+var tagKeyPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedTagKeys are label names already used by the outputs
+var reservedTagKeys = []string{"location", "hostname", "mode", "freq", "freq_mode", "range", "serial"}
+
+// tagFlag collects repeated --tag key=value flags
+type tagFlag map[string]string
+
+// String formats the tags as a comma-separated list
+func (t tagFlag) String() string {
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(t)) {
+		pairs = append(pairs, key+"="+t[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set adds a key=value tag
+func (t tagFlag) Set(value string) error {
+	key, val, found := strings.Cut(value, "=")
+	if !found || val == "" {
+		return fmt.Errorf("invalid tag %q (want key=value)", value)
+	}
+	if !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q (letters, digits, and underscores only)", key)
+	}
+	if slices.Contains(reservedTagKeys, key) {
+		return fmt.Errorf("tag key %q is reserved", key)
+	}
+	t[key] = val
+	return nil
+}
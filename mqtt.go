@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttPublisher publishes each reading as JSON to an MQTT topic; a nil *mqttPublisher is a no-op
+type mqttPublisher struct {
+	client mqtt.Client
+	topic  string
+	qos    byte
+
+	// dropping is set while readings are being dropped so the warning is logged once per outage
+	dropping atomic.Bool
+}
+
+// newMQTTPublisher connects to the broker; the client reconnects on its own after a connection loss
+func newMQTTPublisher(broker, topic, username, password string, qos byte) (*mqttPublisher, error) {
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID("usb-decibel-meter").
+		SetUsername(username).
+		SetPassword(password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(10 * time.Second).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("Lost connection to MQTT broker", "err", err)
+		})
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	token.Wait()
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+	return &mqttPublisher{client: client, topic: topic, qos: qos}, nil
+}
+
+// publish sends a JSON-encoded reading without blocking the read loop; readings are dropped while the broker is unreachable
+func (p *mqttPublisher) publish(payload []byte) {
+	if p == nil {
+		return
+	}
+	p.publishTo(p.topic, payload, false)
+}
+
+// publishTo sends a message to any topic, dropping it while the broker is unreachable
+func (p *mqttPublisher) publishTo(topic string, payload []byte, retain bool) {
+	if !p.client.IsConnectionOpen() {
+		if !p.dropping.Swap(true) {
+			slog.Warn("MQTT broker unreachable, dropping readings until it reconnects")
+		}
+		return
+	}
+	if p.dropping.Swap(false) {
+		slog.Info("MQTT broker reconnected, publishing resumed")
+	}
+
+	token := p.client.Publish(topic, p.qos, retain, payload)
+	go func() {
+		if token.Wait() && token.Error() != nil {
+			slog.Warn("Failed to publish reading to MQTT", "err", token.Error())
+		}
+	}()
+}
+
+// close disconnects from the broker, giving queued messages a moment to be delivered
+func (p *mqttPublisher) close() {
+	if p == nil {
+		return
+	}
+	p.client.Disconnect(250)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// otelMetrics exports the readings as OpenTelemetry metrics over OTLP/HTTP; a nil *otelMetrics is a no-op
+type otelMetrics struct {
+	provider   *sdkmetric.MeterProvider
+	reads      metric.Int64Counter
+	readErrors metric.Int64Counter
+
+	perDevice bool // Adds a serial attribute so several meters can be told apart
+
+	mu     sync.Mutex
+	latest map[string]gm1356.DecibelReading // Latest reading per serial, reported by the gauge callback so only the current mode/range is exported
+}
+
+// newOTelMetrics creates a meter provider exporting to the OTLP/HTTP endpoint, e.g. http://localhost:4318
+func newOTelMetrics(ctx context.Context, endpoint string, perDevice bool) (*otelMetrics, error) {
+	exporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	o := &otelMetrics{
+		perDevice: perDevice,
+		latest:    map[string]gm1356.DecibelReading{},
+		provider: sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+			sdkmetric.WithResource(resource.NewSchemaless(attribute.String("service.name", "usb-decibel-meter"))),
+		),
+	}
+	meter := o.provider.Meter("usb-decibel-meter")
+
+	if o.reads, err = meter.Int64Counter("decibel.reads", metric.WithDescription("Total number of successful readings.")); err != nil {
+		return nil, err
+	}
+	if o.readErrors, err = meter.Int64Counter("decibel.read_errors", metric.WithDescription("Total number of failed reads from the device.")); err != nil {
+		return nil, err
+	}
+	_, err = meter.Float64ObservableGauge("decibel.measured",
+		metric.WithDescription("Most recent sound level measured by the meter."),
+		metric.WithUnit("dB"),
+		metric.WithFloat64Callback(func(_ context.Context, observer metric.Float64Observer) error {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			for _, data := range o.latest {
+				attrs := []attribute.KeyValue{
+					attribute.String("mode", data.Mode),
+					attribute.String("freq_mode", data.FreqMode),
+					attribute.String("range", data.Range),
+				}
+				if o.perDevice {
+					attrs = append(attrs, attribute.String("serial", data.Serial))
+				}
+				observer.Observe(data.Measured, metric.WithAttributes(attrs...))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// observe records a successful reading
+func (o *otelMetrics) observe(data gm1356.DecibelReading) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	o.latest[data.Serial] = data
+	o.mu.Unlock()
+	o.reads.Add(context.Background(), 1)
+}
+
+// observeError records a failed read
+func (o *otelMetrics) observeError() {
+	if o == nil {
+		return
+	}
+	o.readErrors.Add(context.Background(), 1)
+}
+
+// shutdown exports any pending metrics and stops the provider
+func (o *otelMetrics) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return o.provider.Shutdown(ctx)
+}
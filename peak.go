@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// peakHold tracks the highest and lowest level since the last reset, read by the HTTP API and the TUI; a nil *peakHold is a no-op
+type peakHold struct {
+	mu    sync.Mutex
+	lmax  float64
+	lmin  float64
+	count int
+	since time.Time
+}
+
+// peakLevels is a snapshot of the held levels, as served on GET /peak
+type peakLevels struct {
+	Lmax    float64   `json:"lmax"`
+	Lmin    float64   `json:"lmin"`
+	Samples int       `json:"samples"`
+	Since   time.Time `json:"since"`
+}
+
+// newPeakHold creates a tracker holding from now
+func newPeakHold() *peakHold {
+	return &peakHold{since: time.Now()}
+}
+
+// add records a measured level
+func (p *peakHold) add(level float64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.count == 0 || level > p.lmax {
+		p.lmax = level
+	}
+	if p.count == 0 || level < p.lmin {
+		p.lmin = level
+	}
+	p.count++
+}
+
+// reset clears the held levels so tracking starts over from the next reading
+func (p *peakHold) reset() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lmax, p.lmin, p.count = 0, 0, 0
+	p.since = time.Now()
+}
+
+// get returns the held levels; Samples is 0 if nothing was read since the last reset
+func (p *peakHold) get() peakLevels {
+	if p == nil {
+		return peakLevels{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return peakLevels{Lmax: p.lmax, Lmin: p.lmin, Samples: p.count, Since: p.since}
+}
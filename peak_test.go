@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestPeakHold(t *testing.T) {
+	p := newPeakHold()
+	for _, level := range []float64{60, 72.5, 48} {
+		p.add(level)
+	}
+	if got := p.get(); got.Lmax != 72.5 || got.Lmin != 48 || got.Samples != 3 {
+		t.Errorf("get() = %+v, want Lmax 72.5, Lmin 48 over 3 samples", got)
+	}
+
+	p.reset()
+	if got := p.get(); got.Samples != 0 {
+		t.Errorf("get() after reset() = %+v, want no samples", got)
+	}
+
+	var disabled *peakHold
+	disabled.add(90)
+	disabled.reset()
+	if got := disabled.get(); got != (peakLevels{}) {
+		t.Errorf("nil peakHold get() = %+v, want zero levels", got)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxPercentileSamples bounds the reservoir; beyond it samples are kept with decreasing probability so memory stays fixed on long surveys
+const maxPercentileSamples = 100000
+
+// percentileTracker computes statistical levels (L10, L50, L90, ...) over the session; a nil *percentileTracker is a no-op
+type percentileTracker struct {
+	mu        sync.Mutex
+	levels    []float64             // Percentages of time the reported levels are exceeded
+	weighting map[string]*reservoir // Keyed by frequency weighting, since mixing dBA and dBC samples is meaningless
+	order     []string              // Weightings in the order they were first seen
+}
+
+// reservoir is a uniform sample of the levels measured with one frequency weighting
+type reservoir struct {
+	samples []float64
+	seen    int
+}
+
+// parsePercentiles parses a comma-separated list of exceedance percentages such as "10,50,90"
+func parsePercentiles(list string) ([]float64, error) {
+	var levels []float64
+	for _, field := range strings.Split(list, ",") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || n <= 0 || n >= 100 {
+			return nil, fmt.Errorf("invalid percentile %q (must be between 0 and 100)", field)
+		}
+		levels = append(levels, n)
+	}
+	return levels, nil
+}
+
+// add records a measured level
+func (p *percentileTracker) add(measured float64, freqMode string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r := p.weighting[freqMode]
+	if r == nil {
+		if p.weighting == nil {
+			p.weighting = map[string]*reservoir{}
+		}
+		r = &reservoir{}
+		p.weighting[freqMode] = r
+		p.order = append(p.order, freqMode)
+	}
+	r.seen++
+	if len(r.samples) < maxPercentileSamples {
+		r.samples = append(r.samples, measured)
+		return
+	}
+	if i := rand.IntN(r.seen); i < maxPercentileSamples {
+		r.samples[i] = measured
+	}
+}
+
+// exceeded returns the level exceeded n percent of the time, using the nearest-rank method
+func exceeded(sorted []float64, n float64) float64 {
+	rank := int(math.Ceil((100 - n) / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// print writes the statistical levels to w, separately for each weighting seen
+func (p *percentileTracker) print(w io.Writer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, freqMode := range p.order {
+		sorted := slices.Clone(p.weighting[freqMode].samples)
+		slices.Sort(sorted)
+		if len(p.order) == 1 {
+			fmt.Fprintln(w, "Statistical Levels:")
+		} else {
+			fmt.Fprintf(w, "Statistical Levels (%s):\n", freqMode)
+		}
+		for _, n := range p.levels {
+			fmt.Fprintf(w, "  L%-11s %.1f dB\n", strconv.FormatFloat(n, 'f', -1, 64)+":", exceeded(sorted, n))
+		}
+	}
+}
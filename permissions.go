@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// udevRulePath is where permissionHint suggests installing the udev rule
+const udevRulePath = "/etc/udev/rules.d/99-gm1356.rules"
+
+// permissionHint explains how to give the current user access to a meter that is attached but can't be opened
+func permissionHint(vendorID, productID uint16) string {
+	switch runtime.GOOS {
+	case "linux":
+		subsystem, alternative := "hidraw", "If hidraw access can't be granted, a build with -tags libusb opens the meter through libusb instead."
+		if hidBackend == "libusb" {
+			subsystem, alternative = "usb", "This build uses the libusb backend; a build without -tags libusb uses hidraw instead."
+		}
+		return fmt.Sprintf(`The meter is attached, but this user may not open its %s device. Either run as root, or allow access with a udev rule:
+
+  echo 'SUBSYSTEM=="%s", ATTRS{idVendor}=="%04x", ATTRS{idProduct}=="%04x", MODE="0660", TAG+="uaccess"' | sudo tee %s
+  sudo udevadm control --reload-rules && sudo udevadm trigger
+
+then unplug the meter and plug it back in. %s
+`, subsystem, subsystem, vendorID, productID, udevRulePath, alternative)
+	case "darwin":
+		return "The meter is attached, but macOS denied access to it. Allow the terminal under System Settings > Privacy & Security > Input Monitoring, or run with sudo.\n"
+	}
+	return "The meter is attached, but this user isn't allowed to open it. Try running with administrator rights.\n"
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// errLocked is returned by lockFile when another process already holds the lock
+var errLocked = errors.New("file is locked")
+
+// pidFile is a file holding the PID of the running instance, locked for as long as the process lives; a nil *pidFile is a no-op
+type pidFile struct {
+	path string
+	file *os.File
+}
+
+// acquirePidFile locks the file at path and writes the current PID to it, failing if another instance holds the lock
+func acquirePidFile(path string) (*pidFile, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, errLocked) {
+			if pid := readPid(path); pid != 0 {
+				return nil, fmt.Errorf("another instance is already running (pid %d)", pid)
+			}
+			return nil, errors.New("another instance is already running")
+		}
+		return nil, err
+	}
+
+	// The lock, not the file's existence, marks a live instance, so a PID left behind by a crash is simply overwritten
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &pidFile{path: path, file: file}, nil
+}
+
+// readPid returns the PID recorded in the file at path, or 0 if it can't be read
+func readPid(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+// release removes the pidfile and drops the lock
+func (p *pidFile) release() {
+	if p == nil {
+		return
+	}
+	// Removing while still locked keeps a new instance from locking a file that is about to disappear; Windows refuses to remove an open file, so retry after closing
+	err := os.Remove(p.path)
+	p.file.Close()
+	if err != nil {
+		os.Remove(p.path)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// lockFile is a no-op where file locking isn't available, so --pidfile only records the PID
+func lockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// metrics holds the Prometheus collectors updated by the read loop; a nil *metrics is a no-op
+type metrics struct {
+	perDevice  bool // Adds a serial label so several meters can be told apart
+	measured   *prometheus.GaugeVec
+	reads      prometheus.Counter
+	readErrors prometheus.Counter
+}
+
+// newMetrics creates the decibel collectors and registers them with reg; perDevice adds a serial label to the level gauge
+func newMetrics(reg prometheus.Registerer, perDevice bool) *metrics {
+	labels := []string{"mode", "freq_mode", "range"}
+	if perDevice {
+		labels = append(labels, "serial")
+	}
+	m := &metrics{
+		perDevice: perDevice,
+		measured: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "decibel_measured_db",
+			Help: "Most recent sound level measured by the meter, in dB.",
+		}, labels),
+		reads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "decibel_reads_total",
+			Help: "Total number of successful readings.",
+		}),
+		readErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "decibel_read_errors_total",
+			Help: "Total number of failed reads from the device.",
+		}),
+	}
+	reg.MustRegister(m.measured, m.reads, m.readErrors)
+	return m
+}
+
+// observe records a successful reading
+func (m *metrics) observe(data gm1356.DecibelReading) {
+	if m == nil {
+		return
+	}
+	// Drop the previous label set so a mode/range change doesn't leave a stale series behind
+	if m.perDevice {
+		m.measured.DeletePartialMatch(prometheus.Labels{"serial": data.Serial})
+		m.measured.WithLabelValues(data.Mode, data.FreqMode, data.Range, data.Serial).Set(data.Measured)
+	} else {
+		m.measured.Reset()
+		m.measured.WithLabelValues(data.Mode, data.FreqMode, data.Range).Set(data.Measured)
+	}
+	m.reads.Inc()
+}
+
+// observeError records a failed read
+func (m *metrics) observeError() {
+	if m == nil {
+		return
+	}
+	m.readErrors.Inc()
+}
+
+// startMetricsServer serves reg on /metrics at addr; it returns once the listener is bound so address errors surface at startup
+func startMetricsServer(addr string, reg *prometheus.Registry) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
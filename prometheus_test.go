@@ -0,0 +1,53 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"usb-decibel-meter/gm1356"
+)
+
+func TestTagFlagRejectsOutputLabels(t *testing.T) {
+	tests := []struct {
+		tag     string
+		wantErr bool
+	}{
+		{"rack=1", false},
+		{"serial=rack1", true},
+		{"range=wide", true},
+		{"location=kitchen", true},
+		{"bad-key=1", true},
+		{"novalue", true},
+	}
+	for _, tt := range tests {
+		tags := tagFlag{}
+		if err := tags.Set(tt.tag); (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v, wantErr %t", tt.tag, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMetricsLabelsAreReserved(t *testing.T) {
+	// Every variable label the collectors use must be rejected as a --tag, or registering it again as a constant label panics
+	for _, perDevice := range []bool{false, true} {
+		reg := prometheus.NewRegistry()
+		m := newMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"rack": "1"}, reg), perDevice)
+		m.observe(gm1356.DecibelReading{Measured: 60, Mode: "slow", FreqMode: "dBA", Range: "30-130", Serial: "a"})
+
+		families, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Gather() error = %v", err)
+		}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if name := label.GetName(); name != "rack" && !slices.Contains(reservedTagKeys, name) {
+						t.Errorf("%s label %q (perDevice %t) can be passed as a --tag", family.GetName(), name, perDevice)
+					}
+				}
+			}
+		}
+	}
+}
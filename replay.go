@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// replaySource re-reads a CSV log written by --log and returns its rows as readings, then io.EOF
+type replaySource struct {
+	file     *os.File
+	reader   *csv.Reader
+	columns  map[string]int // Column index by header name
+	realtime bool           // Wait out the original gap between rows instead of replaying as fast as possible
+	line     int
+	last     time.Time
+}
+
+// newReplaySource opens a CSV log for replay; files without a header row are assumed to have the default columns
+func newReplaySource(filename string, comma rune, realtime bool) (*replaySource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(file)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	r := &replaySource{file: file, reader: reader, realtime: realtime}
+
+	first, err := reader.Read()
+	if err != nil {
+		file.Close()
+		if err == io.EOF {
+			return nil, errors.New("file is empty")
+		}
+		return nil, err
+	}
+	header := csvHeader
+	if slices.Contains(first, "timestamp") {
+		header = first
+		r.line = 1
+	} else {
+		// No header, so the first row is data: start over to replay it
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+		r.reader = csv.NewReader(file)
+		r.reader.Comma = comma
+		r.reader.FieldsPerRecord = -1
+	}
+
+	r.columns = map[string]int{}
+	for i, name := range header {
+		r.columns[name] = i
+	}
+	for _, name := range []string{"timestamp", "measured"} {
+		if _, ok := r.columns[name]; !ok {
+			file.Close()
+			return nil, fmt.Errorf("no %q column, so this is not a readings log (--aggregate logs can't be replayed)", name)
+		}
+	}
+	return r, nil
+}
+
+// Read returns the next row as a reading, skipping rows that can't be parsed; it returns io.EOF at the end of the file or once ctx is cancelled
+func (r *replaySource) Read(ctx context.Context) (gm1356.DecibelReading, error) {
+	for {
+		record, err := r.reader.Read()
+		if err != nil {
+			return gm1356.DecibelReading{}, err
+		}
+		r.line++
+		data, err := r.parse(record)
+		if err != nil {
+			slog.Warn("Skipping unreadable replay row", "line", r.line, "err", err)
+			continue
+		}
+
+		if r.realtime && !r.last.IsZero() && !sleepContext(ctx, data.Time.Sub(r.last)) {
+			return gm1356.DecibelReading{}, io.EOF
+		}
+		r.last = data.Time
+		return data, nil
+	}
+}
+
+// parse converts a CSV row back into a reading, re-rendering the timestamp in the current --timestamp-format
+func (r *replaySource) parse(record []string) (gm1356.DecibelReading, error) {
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	at, err := parseReplayTimestamp(field("timestamp"))
+	if err != nil {
+		return gm1356.DecibelReading{}, err
+	}
+	measured, err := strconv.ParseFloat(field("measured"), 64)
+	if err != nil {
+		return gm1356.DecibelReading{}, fmt.Errorf("invalid measured value: %w", err)
+	}
+
+	data := gm1356.DecibelReading{
+		Time:        at,
+		Measured:    measured,
+		RawMeasured: measured,
+		Mode:        field("mode"),
+		FreqMode:    field("freqMode"),
+		Range:       field("range"),
+		OutOfRange:  gm1356.ParseOutOfRange(measured, field("range")),
+		Serial:      field("serial"),
+		Raw:         field("raw"),
+	}
+	var ok bool
+	if data.Timestamp, ok = formatTimestamp(at, tsFormat); !ok {
+		data.Timestamp = at.Format(gm1356.TimestampLayout)
+	}
+	return data, nil
+}
+
+// parseReplayTimestamp accepts a timestamp in any --timestamp-format
+func parseReplayTimestamp(s string) (time.Time, error) {
+	if at, err := time.Parse(gm1356.TimestampLayout, s); err == nil {
+		return at, nil
+	}
+	if at, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return at, nil
+	}
+	if millis, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(millis).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// ReadConfig is unsupported: a replayed log has no device to query
+func (r *replaySource) ReadConfig(ctx context.Context) (byte, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// SetConfig is unsupported: a replayed log has no device to configure
+func (r *replaySource) SetConfig(ctx context.Context, settings gm1356.Settings) (byte, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// Info describes the replayed file in place of a device
+func (r *replaySource) Info() gm1356.DeviceInfo {
+	return gm1356.DeviceInfo{Manufacturer: "usb-decibel-meter", Product: "CSV replay of " + r.file.Name()}
+}
+
+// Reopen is unsupported: the file is read once
+func (r *replaySource) Reopen() error {
+	return errors.ErrUnsupported
+}
+
+// Close closes the file
+func (r *replaySource) Close() error {
+	return r.file.Close()
+}
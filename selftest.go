@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// selfTestLine lays out one self-test step: status, step name padded to the longest, and detail
+const selfTestLine = "  %s  %-24s  %s"
+
+// runSelfTest checks that a meter answers the capture handshake with a packet that decodes to plausible values, printing a PASS or FAIL line per step; it reports whether every step passed
+func runSelfTest(ctx context.Context, w io.Writer, meter source) bool {
+	info := meter.Info()
+	fmt.Fprintf(w, "Self-test of %s %s (serial %s)\n", info.Manufacturer, info.Product, info.Serial)
+	passed := true
+	check := func(ok bool, step, detail string) bool {
+		status := "PASS"
+		if !ok {
+			status, passed = "FAIL", false
+		}
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf(selfTestLine, status, step, detail), " "))
+		return ok
+	}
+	defer func() {
+		result := "PASS"
+		if !passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(w, "Result: %s\n\n", result)
+	}()
+
+	check(true, "Open device", "")
+	config, err := meter.ReadConfig(ctx)
+	if !check(err == nil, "Capture command answered", errDetail(err, fmt.Sprintf("config byte %#02x", config))) {
+		return false
+	}
+	data, err := meter.Read(ctx)
+	if !check(err == nil, "Reading decoded", errDetail(err, fmt.Sprintf("%s %s, %s, %s range", formatLevel(data.Measured), data.FreqMode, data.Mode, data.Range))) {
+		return false
+	}
+	check(!data.UnknownConfig, "Range recognized", fmt.Sprintf("config byte %#02x decodes to range %s", data.Config, data.Range))
+	levelDetail := fmt.Sprintf("%s dB", formatLevel(data.RawMeasured))
+	switch {
+	case !plausible(data.RawMeasured):
+		levelDetail += fmt.Sprintf(", outside %g-%g dB; another --decode-variant may suit this firmware", minValid, maxValid)
+	case data.OutOfRange:
+		levelDetail += ", outside the selected range, but a plausible level"
+	}
+	check(plausible(data.RawMeasured), "Level plausible", levelDetail)
+	return passed
+}
+
+// printOpenFailure reports a self-test that couldn't get past opening the device, in the layout of runSelfTest
+func printOpenFailure(w io.Writer, serial string, err error) {
+	if serial == "" {
+		serial = fmt.Sprintf("%04x:%04x", vendorID, productID)
+	}
+	fmt.Fprintf(w, "Self-test of meter %s\n", serial)
+	fmt.Fprintf(w, selfTestLine+"\n", "FAIL", "Open device", err)
+	fmt.Fprintf(w, "Result: FAIL\n\n")
+}
+
+// errDetail describes the outcome of a step: the error if it failed, otherwise detail
+func errDetail(err error, detail string) string {
+	if err != nil {
+		return err.Error()
+	}
+	return detail
+}
@@ -0,0 +1,39 @@
+package main
+
+import "usb-decibel-meter/gm1356"
+
+// smoother averages the last N levels in the energy domain; a nil *smoother is a no-op
+type smoother struct {
+	energies []float64 // Ring buffer of sample energies
+	next     int
+	filled   bool
+	sum      float64
+	freqMode string // Weighting of the samples in the window
+}
+
+// newSmoother creates a smoother over a window of n samples
+func newSmoother(n int) *smoother {
+	return &smoother{energies: make([]float64, n)}
+}
+
+// add records a level and returns the energy mean of the window so far, in dB; a change of weighting starts a fresh window
+func (s *smoother) add(level float64, freqMode string) float64 {
+	if freqMode != s.freqMode {
+		clear(s.energies)
+		s.next, s.filled, s.sum = 0, false, 0
+		s.freqMode = freqMode
+	}
+	energy := gm1356.Energy(level)
+	s.sum += energy - s.energies[s.next]
+	s.energies[s.next] = energy
+	s.next = (s.next + 1) % len(s.energies)
+	if s.next == 0 {
+		s.filled = true
+	}
+
+	count := s.next
+	if s.filled {
+		count = len(s.energies)
+	}
+	return gm1356.Level(s.sum / float64(count))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// sqliteBatch is the default batch limit for SQLite commits; whichever is reached first triggers a commit
+var sqliteBatch = flushPolicy{interval: time.Second, rows: 100}
+
+// sqliteSchema creates the readings table and its timestamp index; serial is only filled in when several meters are read
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS readings (
+	timestamp TEXT NOT NULL,
+	measured  REAL NOT NULL,
+	mode      TEXT NOT NULL,
+	freq_mode TEXT NOT NULL,
+	"range"   TEXT NOT NULL,
+	serial    TEXT
+);
+CREATE INDEX IF NOT EXISTS readings_timestamp ON readings (timestamp);
+`
+
+// sqliteLog inserts readings into a SQLite database, committing in batches; a nil *sqliteLog is a no-op
+type sqliteLog struct {
+	mu         sync.Mutex
+	db         *sql.DB
+	insert     *sql.Stmt
+	tx         *sql.Tx
+	batch      flushPolicy
+	pending    int
+	lastCommit time.Time
+}
+
+// setupSQLiteLog opens or creates the database and creates the schema if the file is new, adding the serial column to older files when several meters are read; limits set in batch replace the default batching
+func setupSQLiteLog(filename string, batch flushPolicy) (*sqliteLog, error) {
+	fileExists := fileExists(filename)
+
+	db, err := sql.Open("sqlite3", filename)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists {
+		if _, err := db.Exec(sqliteSchema); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	query := `INSERT INTO readings (timestamp, measured, mode, freq_mode, "range") VALUES (?, ?, ?, ?, ?)`
+	if multiDevice {
+		if err := addSerialColumn(db); err != nil {
+			db.Close()
+			return nil, err
+		}
+		query = `INSERT INTO readings (timestamp, measured, mode, freq_mode, "range", serial) VALUES (?, ?, ?, ?, ?, ?)`
+	}
+	insert, err := db.Prepare(query)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteLog{db: db, insert: insert, batch: batch.withDefaults(sqliteBatch), lastCommit: time.Now()}, nil
+}
+
+// addSerialColumn adds the serial column to a readings table created before it existed
+func addSerialColumn(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('readings') WHERE name = 'serial'`).Scan(&found); err != nil || found > 0 {
+		return err
+	}
+	_, err := db.Exec(`ALTER TABLE readings ADD COLUMN serial TEXT`)
+	return err
+}
+
+// write adds a reading to the current batch, committing it once it is full or old enough
+func (l *sqliteLog) write(data gm1356.DecibelReading) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.tx == nil {
+		tx, err := l.db.Begin()
+		if err != nil {
+			return err
+		}
+		l.tx = tx
+	}
+	args := []any{data.Timestamp, data.Measured, data.Mode, data.FreqMode, data.Range}
+	if multiDevice {
+		args = append(args, data.Serial)
+	}
+	if _, err := l.tx.Stmt(l.insert).Exec(args...); err != nil {
+		return err
+	}
+	l.pending++
+
+	if l.batch.due(l.pending, l.lastCommit) {
+		return l.commit()
+	}
+	return nil
+}
+
+// commit commits the current batch; callers must hold mu
+func (l *sqliteLog) commit() error {
+	l.lastCommit = time.Now()
+	if l.tx == nil {
+		return nil
+	}
+	err := l.tx.Commit()
+	l.tx = nil
+	l.pending = 0
+	return err
+}
+
+// close commits any pending readings and closes the database
+func (l *sqliteLog) close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.commit()
+	l.insert.Close()
+	if closeErr := l.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
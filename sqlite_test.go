@@ -0,0 +1,63 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"usb-decibel-meter/gm1356"
+)
+
+func TestSQLiteLogSerial(t *testing.T) {
+	defer func(saved bool) { multiDevice = saved }(multiDevice)
+	tests := []struct {
+		name        string
+		schema      string // Table created before the log opens the file; empty lets the log create it
+		multiDevice bool
+		want        sql.NullString
+	}{
+		{"one meter", "", false, sql.NullString{}},
+		{"several meters", "", true, sql.NullString{String: "a", Valid: true}},
+		{"column added to an older file", `CREATE TABLE readings (timestamp TEXT NOT NULL, measured REAL NOT NULL, mode TEXT NOT NULL, freq_mode TEXT NOT NULL, "range" TEXT NOT NULL)`, true, sql.NullString{String: "a", Valid: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "readings.db")
+			if tt.schema != "" {
+				db, err := sql.Open("sqlite3", filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec(tt.schema); err != nil {
+					t.Fatal(err)
+				}
+				db.Close()
+			}
+
+			multiDevice = tt.multiDevice
+			l, err := setupSQLiteLog(filename, flushPolicy{})
+			if err != nil {
+				t.Fatalf("setupSQLiteLog() error = %v", err)
+			}
+			if err := l.write(gm1356.DecibelReading{Timestamp: "2025-03-01 05:04:00 UTC", Measured: 42, Mode: "slow", FreqMode: "dBA", Range: "30-130", Serial: "a"}); err != nil {
+				t.Fatalf("write() error = %v", err)
+			}
+			if err := l.close(); err != nil {
+				t.Fatalf("close() error = %v", err)
+			}
+
+			db, err := sql.Open("sqlite3", filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			var got sql.NullString
+			if err := db.QueryRow(`SELECT serial FROM readings`).Scan(&got); err != nil {
+				t.Fatalf("reading back the serial: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("serial = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// sessionStats accumulates a summary of the readings taken during the session; a nil *sessionStats is a no-op
+type sessionStats struct {
+	mu         sync.Mutex
+	count      int
+	levels     map[string]*levelSummary // Keyed by frequency weighting, since dBA and dBC can't be averaged together
+	order      []string                 // Weightings in the order they were first seen
+	readErrors int
+	unknownCfg int // Readings with an unrecognized config byte
+	dropped    int // Implausible readings dropped by --min-valid/--max-valid
+	stale      int // Repeated packets skipped by --dedup
+	excluded   int // Out-of-range readings left out by --exclude-out-of-range
+}
+
+// levelSummary is the min, max, and mean of the readings taken with one weighting
+type levelSummary struct {
+	count int
+	min   float64
+	max   float64
+	sum   float64
+}
+
+// add records a measured level
+func (s *sessionStats) add(measured float64, freqMode string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.levels[freqMode]
+	if l == nil {
+		if s.levels == nil {
+			s.levels = map[string]*levelSummary{}
+		}
+		l = &levelSummary{}
+		s.levels[freqMode] = l
+		s.order = append(s.order, freqMode)
+	}
+	if l.count == 0 || measured < l.min {
+		l.min = measured
+	}
+	if l.count == 0 || measured > l.max {
+		l.max = measured
+	}
+	l.sum += measured
+	l.count++
+	s.count++
+}
+
+// addError records a failed read
+func (s *sessionStats) addError() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readErrors++
+}
+
+// addUnknownConfig records a reading with an unrecognized config byte
+func (s *sessionStats) addUnknownConfig() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unknownCfg++
+}
+
+// addImplausible records a reading dropped as outside --min-valid/--max-valid
+func (s *sessionStats) addImplausible() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped++
+}
+
+// addStale records a repeated packet skipped by --dedup
+func (s *sessionStats) addStale() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale++
+}
+
+// addExcluded records an out-of-range reading left out of the statistics by --exclude-out-of-range
+func (s *sessionStats) addExcluded() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.excluded++
+}
+
+// print writes the session summary to w, with the levels broken down by weighting if it changed during the session
+func (s *sessionStats) print(w io.Writer) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintln(w, "Session Summary:")
+	fmt.Fprintf(w, "  Samples:     %d\n", s.count)
+	switch len(s.order) {
+	case 0:
+	case 1:
+		l := s.levels[s.order[0]]
+		fmt.Fprintf(w, "  Min:         %.1f dB\n", l.min)
+		fmt.Fprintf(w, "  Max:         %.1f dB\n", l.max)
+		fmt.Fprintf(w, "  Mean:        %.1f dB\n", l.sum/float64(l.count))
+	default:
+		fmt.Fprintln(w, "  Weighting changed during the session, levels are reported per weighting:")
+		for _, freqMode := range s.order {
+			l := s.levels[freqMode]
+			fmt.Fprintf(w, "  %-4s %d samples, min %.1f, max %.1f, mean %.1f dB\n", freqMode+":", l.count, l.min, l.max, l.sum/float64(l.count))
+		}
+	}
+	fmt.Fprintf(w, "  Read errors: %d\n", s.readErrors)
+	if s.unknownCfg > 0 {
+		fmt.Fprintf(w, "  Unknown config bytes: %d\n", s.unknownCfg)
+	}
+	if s.dropped > 0 {
+		fmt.Fprintf(w, "  Implausible readings dropped: %d\n", s.dropped)
+	}
+	if s.stale > 0 {
+		fmt.Fprintf(w, "  Repeated packets skipped: %d\n", s.stale)
+	}
+	if s.excluded > 0 {
+		fmt.Fprintf(w, "  Out-of-range readings excluded: %d\n", s.excluded)
+	}
+}
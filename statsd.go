@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// statsdQueue is how many gauges may wait to be sent; newer ones are dropped beyond this rather than stalling the reader
+const statsdQueue = 256
+
+// statsdTagEscaper replaces the characters that would end a DogStatsD tag or tag list
+var statsdTagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_")
+
+// statsdWriter sends each reading as a decibel.measured gauge over UDP without waiting on the network; a nil *statsdWriter is a no-op
+type statsdWriter struct {
+	conn      net.Conn
+	dogstatsd bool        // Append mode, weighting, range, and metadata as DogStatsD tags
+	tags      [][2]string // Extra metadata tags
+
+	queue chan string
+	done  chan struct{}
+}
+
+// newStatsdWriter resolves addr and starts the sender; UDP has no handshake, so an absent collector is only noticed as dropped packets
+func newStatsdWriter(addr string, dogstatsd bool, tags [][2]string) (*statsdWriter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	w := &statsdWriter{conn: conn, dogstatsd: dogstatsd, tags: tags, queue: make(chan string, statsdQueue), done: make(chan struct{})}
+	go w.sendLoop()
+	return w, nil
+}
+
+// formatStatsdGauge renders a reading as a gauge, with DogStatsD tags if enabled; plain StatsD has no tags
+func formatStatsdGauge(data gm1356.DecibelReading, dogstatsd bool, extra [][2]string) string {
+	line := "decibel.measured:" + strconv.FormatFloat(data.Measured, 'f', -1, 64) + "|g"
+	if !dogstatsd {
+		return line
+	}
+	tags := [][2]string{{"mode", data.Mode}, {"freq", data.FreqMode}, {"range", data.Range}}
+	if multiDevice {
+		tags = append(tags, [2]string{"serial", data.Serial})
+	}
+	tags = append(tags, extra...)
+	rendered := make([]string, len(tags))
+	for i, tag := range tags {
+		rendered[i] = statsdTagEscaper.Replace(tag[0]) + ":" + statsdTagEscaper.Replace(tag[1])
+	}
+	return line + "|#" + strings.Join(rendered, ",")
+}
+
+// write queues a reading's gauge, dropping it if the sender has fallen behind
+func (w *statsdWriter) write(data gm1356.DecibelReading) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.queue <- formatStatsdGauge(data, w.dogstatsd, w.tags):
+	default:
+		slog.Debug("StatsD queue full, dropping gauge")
+	}
+}
+
+// sendLoop sends queued gauges until the queue is closed; send errors, such as a refused port, are only logged at debug level
+func (w *statsdWriter) sendLoop() {
+	defer close(w.done)
+	for line := range w.queue {
+		if _, err := w.conn.Write([]byte(line)); err != nil {
+			slog.Debug("Failed to send StatsD gauge", "err", err)
+		}
+	}
+}
+
+// close sends any gauges still queued and closes the socket
+func (w *statsdWriter) close() {
+	if w == nil {
+		return
+	}
+	close(w.queue)
+	<-w.done
+	w.conn.Close()
+}
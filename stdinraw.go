@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// rawFrameSource decodes hex-encoded HID packets, one per line, from another acquisition process instead of the device
+type rawFrameSource struct {
+	lines       chan string
+	err         error // Set before lines is closed
+	profile     *gm1356.DeviceProfile
+	variant     gm1356.DecodeVariant
+	battery     bool // Decode the battery-low bit, as with --decode-battery
+	calibration float64
+	now         func() time.Time
+	line        int
+}
+
+// newRawFrameSource starts reading frames from r; lines are read in the background so shutdown isn't stuck on a blocked read
+func newRawFrameSource(ctx context.Context, r io.Reader, profile *gm1356.DeviceProfile, variant gm1356.DecodeVariant, battery bool, calibration float64, now func() time.Time) *rawFrameSource {
+	s := &rawFrameSource{lines: make(chan string), profile: profile, variant: variant, battery: battery, calibration: calibration, now: now}
+	go func() {
+		defer close(s.lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case s.lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		s.err = scanner.Err()
+	}()
+	return s
+}
+
+// Read decodes the next frame, skipping lines that aren't valid frames; it returns io.EOF at the end of the input or once ctx is cancelled
+func (s *rawFrameSource) Read(ctx context.Context) (gm1356.DecibelReading, error) {
+	for {
+		var line string
+		var ok bool
+		select {
+		case line, ok = <-s.lines:
+		case <-ctx.Done():
+			return gm1356.DecibelReading{}, io.EOF
+		}
+		if !ok {
+			if s.err != nil {
+				return gm1356.DecibelReading{}, s.err
+			}
+			return gm1356.DecibelReading{}, io.EOF
+		}
+		s.line++
+
+		frame, err := parseRawFrame(line)
+		if err != nil {
+			slog.Warn("Skipping invalid raw frame", "line", s.line, "err", err)
+			continue
+		}
+		if frame == nil {
+			continue // Blank line
+		}
+		data, err := s.profile.Decode(frame, s.now(), s.variant, s.battery, s.calibration)
+		if err != nil {
+			slog.Warn("Skipping invalid raw frame", "line", s.line, "err", err)
+			continue
+		}
+		if includeRaw {
+			data.Raw = hex.EncodeToString(frame)
+		}
+		return data, nil
+	}
+}
+
+// parseRawFrame decodes a line of hex, such as "028d000000000000" or "02 8d 00 00 00 00 00 00"; a blank line returns nil
+func parseRawFrame(line string) ([]byte, error) {
+	line = strings.Join(strings.Fields(line), "")
+	line = strings.TrimPrefix(strings.TrimPrefix(line, "0x"), "0X")
+	if line == "" {
+		return nil, nil
+	}
+	frame, err := hex.DecodeString(line)
+	if err != nil {
+		return nil, fmt.Errorf("not a hex frame: %w", err)
+	}
+	return frame, nil
+}
+
+// ReadConfig is unsupported: frames arrive without a device to query
+func (s *rawFrameSource) ReadConfig(ctx context.Context) (byte, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// SetConfig is unsupported: frames arrive without a device to configure
+func (s *rawFrameSource) SetConfig(ctx context.Context, settings gm1356.Settings) (byte, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// Info describes the input in place of a device
+func (s *rawFrameSource) Info() gm1356.DeviceInfo {
+	return gm1356.DeviceInfo{Manufacturer: "usb-decibel-meter", Product: "Raw frames from stdin"}
+}
+
+// Reopen is unsupported: the input is read once
+func (s *rawFrameSource) Reopen() error {
+	return errors.ErrUnsupported
+}
+
+// Close is a no-op; stdin stays open
+func (s *rawFrameSource) Close() error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"usb-decibel-meter/gm1356"
+)
+
+func TestRawFrameSourceRead(t *testing.T) {
+	at := time.Date(2025, 3, 1, 5, 4, 0, 0, time.UTC)
+	input := "01 3a 0a 00 00 00 00 00\n\nnot hex\n0x028d52\n"
+	s := newRawFrameSource(t.Context(), strings.NewReader(input), &gm1356.ProfileGM1356, gm1356.VariantStandard, true, 1.5, func() time.Time { return at })
+
+	want := []gm1356.DecibelReading{
+		{Measured: 32.9, RawMeasured: 31.4, Range: "50-100", BatteryLow: true},
+		{Measured: 66.8, RawMeasured: 65.3, Range: "50-100", FreqMode: "dBC"},
+	}
+	for i, w := range want {
+		got, err := s.Read(t.Context())
+		if err != nil {
+			t.Fatalf("Read() %d error = %v", i, err)
+		}
+		if got.RawMeasured != w.RawMeasured || math.Abs(got.Measured-w.Measured) > 1e-9 || got.Range != w.Range || got.BatteryLow != w.BatteryLow || !got.Time.Equal(at) {
+			t.Errorf("Read() %d = %+v, want measured %v (raw %v), range %s, batteryLow %t", i, got, w.Measured, w.RawMeasured, w.Range, w.BatteryLow)
+		}
+	}
+	if _, err := s.Read(t.Context()); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() at the end of the input error = %v, want io.EOF", err)
+	}
+}
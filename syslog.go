@@ -0,0 +1,84 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// syslogFacilities maps --syslog-facility names to syslog facilities
+var syslogFacilities = map[string]syslog.Priority{
+	"user": syslog.LOG_USER, "daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2, "local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5, "local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+// syslogSeverities maps --syslog-severity names to the writer method logging at that severity
+var syslogSeverities = map[string]func(w *syslog.Writer, msg string) error{
+	"debug":   (*syslog.Writer).Debug,
+	"info":    (*syslog.Writer).Info,
+	"notice":  (*syslog.Writer).Notice,
+	"warning": (*syslog.Writer).Warning,
+	"err":     (*syslog.Writer).Err,
+	"crit":    (*syslog.Writer).Crit,
+	"alert":   (*syslog.Writer).Alert,
+	"emerg":   (*syslog.Writer).Emerg,
+}
+
+// syslogOutput sends each reading as a JSON message to syslog; a nil *syslogOutput is a no-op
+type syslogOutput struct {
+	writer    *syslog.Writer
+	send      func(w *syslog.Writer, msg string) error
+	threshold float64 // Readings above this are sent at warning severity (0 = never escalate)
+}
+
+// newSyslogOutput connects to the local syslog daemon, or to addr ("udp://host:514", "tcp://host:514", or "host:514" for UDP) if given
+func newSyslogOutput(addr, facility, severity string, threshold float64) (*syslogOutput, error) {
+	priority, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown facility %q (valid choices: %s)", facility, strings.Join(slices.Sorted(maps.Keys(syslogFacilities)), ", "))
+	}
+	send, ok := syslogSeverities[severity]
+	if !ok {
+		return nil, fmt.Errorf("unknown severity %q (valid choices: %s)", severity, strings.Join(slices.Sorted(maps.Keys(syslogSeverities)), ", "))
+	}
+
+	network := ""
+	if addr != "" {
+		network = "udp"
+		if scheme, host, found := strings.Cut(addr, "://"); found {
+			if scheme != "udp" && scheme != "tcp" {
+				return nil, fmt.Errorf("unsupported syslog network %q (valid choices: udp, tcp)", scheme)
+			}
+			network, addr = scheme, host
+		}
+	}
+	writer, err := syslog.Dial(network, addr, priority|syslog.LOG_INFO, "usb-decibel-meter")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogOutput{writer: writer, send: send, threshold: threshold}, nil
+}
+
+// write sends a JSON-encoded reading, escalating to warning severity while the level is above the alert threshold
+func (s *syslogOutput) write(payload []byte, level float64) error {
+	if s == nil {
+		return nil
+	}
+	if s.threshold > 0 && level > s.threshold {
+		return s.writer.Warning(string(payload))
+	}
+	return s.send(s.writer, string(payload))
+}
+
+// close closes the connection to the syslog daemon
+func (s *syslogOutput) close() {
+	if s == nil {
+		return
+	}
+	s.writer.Close()
+}
@@ -0,0 +1,21 @@
+//go:build windows || plan9
+
+package main
+
+import "errors"
+
+// syslogOutput is unavailable on this platform; a nil *syslogOutput is a no-op
+type syslogOutput struct{}
+
+// newSyslogOutput always fails, since the standard library has no syslog client here
+func newSyslogOutput(addr, facility, severity string, threshold float64) (*syslogOutput, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
+
+// write does nothing
+func (s *syslogOutput) write(payload []byte, level float64) error {
+	return nil
+}
+
+// close does nothing
+func (s *syslogOutput) close() {}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// Timestamp formats
+const (
+	timestampDefault = "default" // gm1356.TimestampLayout, e.g. "2025-03-01 05:04:00 UTC"
+	timestampRFC3339 = "rfc3339" // RFC 3339 with millisecond precision
+	timestampUnix    = "unix"    // Milliseconds since the Unix epoch
+)
+
+// rfc3339Millis is RFC 3339 with a fixed millisecond fraction
+const rfc3339Millis = "2006-01-02T15:04:05.000Z07:00"
+
+// formatTimestamp renders t in the given --timestamp-format; ok is false for an unknown format
+func formatTimestamp(t time.Time, format string) (string, bool) {
+	switch format {
+	case timestampRFC3339:
+		return t.Format(rfc3339Millis), true
+	case timestampUnix:
+		return strconv.FormatInt(t.UnixMilli(), 10), true
+	}
+	return "", false
+}
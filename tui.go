@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+
+	"usb-decibel-meter/gm1356"
+)
+
+// Chart scale and history kept for the scrolling bar graph
+const (
+	tuiChartMin = 30.0
+	tuiChartMax = 130.0
+	tuiHistory  = 1024
+)
+
+// tuiDigits is a 3x5 block font for the big level display
+var tuiDigits = map[rune][5]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", "###", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	'.': {"   ", "   ", "   ", "   ", " # "},
+	' ': {"   ", "   ", "   ", "   ", "   "},
+	'-': {"   ", "   ", "###", "   ", "   "},
+}
+
+// tuiBars are the partial block characters used for the top of each bar, in eighths
+var tuiBars = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// tui is a full-screen live display of the current level and a scrolling bar graph of recent readings
+type tui struct {
+	mu        sync.Mutex
+	screen    tcell.Screen
+	threshold float64   // Levels above this are drawn in red
+	peaks     *peakHold // Lmax/Lmin shown beside the level, reset with 'r'
+
+	latest  gm1356.DecibelReading
+	history []float64 // Most recent level last
+	logLine string    // Last diagnostic message, shown at the bottom
+}
+
+// newTUI takes over the terminal and draws an empty display
+func newTUI(threshold float64, peaks *peakHold) (*tui, error) {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+	if err := screen.Init(); err != nil {
+		return nil, err
+	}
+	t := &tui{screen: screen, threshold: threshold, peaks: peaks}
+	t.mu.Lock()
+	t.draw()
+	t.mu.Unlock()
+	return t, nil
+}
+
+// run handles keyboard and resize events until the screen is closed, calling quit on 'q', Esc, or Ctrl-C and resetting the peaks on 'r'
+func (t *tui) run(quit func()) {
+	for {
+		switch ev := t.screen.PollEvent().(type) {
+		case nil:
+			return
+		case *tcell.EventKey:
+			if ev.Key() == tcell.KeyCtrlC || ev.Key() == tcell.KeyEscape || ev.Rune() == 'q' {
+				quit()
+			}
+			if ev.Rune() == 'r' {
+				t.peaks.reset()
+				t.mu.Lock()
+				t.draw()
+				t.mu.Unlock()
+			}
+		case *tcell.EventResize:
+			t.mu.Lock()
+			t.screen.Sync()
+			t.draw()
+			t.mu.Unlock()
+		}
+	}
+}
+
+// update shows a new reading; a nil *tui is a no-op
+func (t *tui) update(data gm1356.DecibelReading) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.latest = data
+	t.history = append(t.history, data.Measured)
+	if len(t.history) > tuiHistory {
+		t.history = t.history[len(t.history)-tuiHistory:]
+	}
+	t.draw()
+}
+
+// Write shows the last line of diagnostic output in the status area, so slog can log to the display
+func (t *tui) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if line := strings.TrimSpace(string(p)); line != "" {
+		t.logLine = line[strings.LastIndex(line, "\n")+1:]
+		t.draw()
+	}
+	return len(p), nil
+}
+
+// close restores the terminal
+func (t *tui) close() {
+	if t == nil {
+		return
+	}
+	t.screen.Fini()
+}
+
+// levelStyle colors a level red above the threshold and green otherwise
+func (t *tui) levelStyle(level float64) tcell.Style {
+	if level > t.threshold {
+		return tcell.StyleDefault.Foreground(tcell.ColorRed)
+	}
+	return tcell.StyleDefault.Foreground(tcell.ColorGreen)
+}
+
+// draw redraws the whole screen; the caller must hold t.mu
+func (t *tui) draw() {
+	t.screen.Clear()
+	width, height := t.screen.Size()
+	bold := tcell.StyleDefault.Bold(true)
+
+	t.text(0, 0, "GM1356 Sound Level Meter", bold)
+	t.text(width-len("r: reset peaks  q: quit"), 0, "r: reset peaks  q: quit", tcell.StyleDefault)
+
+	// Big current level
+	if len(t.history) > 0 {
+		style := t.levelStyle(t.latest.Measured)
+		x := 2
+		for _, r := range fmt.Sprintf("%5.1f", t.latest.Measured) {
+			for row, line := range tuiDigits[r] {
+				for col, c := range line {
+					if c == '#' {
+						t.screen.SetContent(x+col, 2+row, '█', nil, style)
+					}
+				}
+			}
+			x += 4
+		}
+		t.text(x, 6, t.latest.FreqMode, style.Bold(true))
+		if levels := t.peaks.get(); levels.Samples > 0 {
+			t.text(x+4, 3, fmt.Sprintf("Lmax %5.1f", levels.Lmax), t.levelStyle(levels.Lmax))
+			t.text(x+4, 5, fmt.Sprintf("Lmin %5.1f", levels.Lmin), t.levelStyle(levels.Lmin))
+		}
+	} else {
+		t.text(2, 4, "Waiting for readings...", tcell.StyleDefault)
+	}
+
+	// Scrolling bar graph between the big number and the status lines
+	chartTop, chartBottom := 8, height-3
+	if chartHeight := chartBottom - chartTop + 1; chartHeight > 0 {
+		visible := t.history[max(len(t.history)-width, 0):]
+		start := width - len(visible) // Newest reading on the right edge
+		for i, level := range visible {
+			eighths := int((level - tuiChartMin) / (tuiChartMax - tuiChartMin) * float64(chartHeight*8))
+			eighths = min(max(eighths, 1), chartHeight*8)
+			style := t.levelStyle(level)
+			for row := 0; row < chartHeight && eighths > 0; row++ {
+				t.screen.SetContent(start+i, chartBottom-row, tuiBars[min(eighths, 8)], nil, style)
+				eighths -= 8
+			}
+		}
+	}
+
+	// Status line with the device settings, and the last diagnostic message
+	if len(t.history) > 0 {
+		status := fmt.Sprintf("%s | %s | %s dB", t.latest.Mode, t.latest.FreqMode, t.latest.Range)
+		if t.latest.MaxHold {
+			status += " | max-hold"
+		}
+		if t.latest.Serial != "" {
+			status += " | serial " + t.latest.Serial
+		}
+		t.text(0, height-2, status, bold)
+		if t.latest.OutOfRange {
+			t.text(len(status)+3, height-2, "OUT OF RANGE", bold.Foreground(tcell.ColorRed))
+		}
+	}
+	t.text(0, height-1, t.logLine, tcell.StyleDefault.Dim(true))
+
+	t.screen.Show()
+}
+
+// text draws a string starting at x, y
+func (t *tui) text(x, y int, s string, style tcell.Style) {
+	for _, r := range s {
+		t.screen.SetContent(x, y, r, nil, style)
+		x++
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketWriteTimeout bounds how long a single message may take to reach a client
+const websocketWriteTimeout = 5 * time.Second
+
+// upgrader accepts WebSocket connections from any origin so browser dashboards on other hosts can subscribe
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// websocketHandler streams every broadcast reading to the connected client as a JSON text message
+func websocketHandler(feed *broadcaster[[]byte]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Warn("WebSocket upgrade failed", "err", err)
+			return
+		}
+		defer conn.Close()
+
+		messages := feed.subscribe()
+		defer feed.unsubscribe(messages)
+
+		// Discard anything the client sends; a read error means it went away
+		gone := make(chan struct{})
+		go func() {
+			defer close(gone)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-gone:
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					// Dropped for falling behind, or shutting down
+					conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// startWebSocketServer serves the reading feed on /ws at addr; it returns once the listener is bound so address errors surface at startup
+func startWebSocketServer(addr string, feed *broadcaster[[]byte]) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", websocketHandler(feed))
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
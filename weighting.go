@@ -0,0 +1,14 @@
+package main
+
+import "usb-decibel-meter/gm1356"
+
+// estimateOtherWeighting approximates the level in the weighting the meter isn't set to, assuming dBC = dBA + offset
+func estimateOtherWeighting(level float64, freqMode string, offset float64) *gm1356.WeightingEstimate {
+	switch freqMode {
+	case "dBA":
+		return &gm1356.WeightingEstimate{FreqMode: "dBC", Measured: roundLevel(level + offset), Estimated: true}
+	case "dBC":
+		return &gm1356.WeightingEstimate{FreqMode: "dBA", Measured: roundLevel(level - offset), Estimated: true}
+	}
+	return nil
+}